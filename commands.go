@@ -0,0 +1,497 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uolter/cptfcvars/tfcloud"
+)
+
+// command is a subcommand of the cli, with its own flags.
+type command struct {
+	name     string
+	aliases  []string
+	summary  string
+	examples []string
+	// api reports whether the command calls the api: the token is looked
+	// up and the workspaces are resolved before running it.
+	api      bool
+	flags    func(fs *flag.FlagSet)
+	validate func()
+	run      func()
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "read",
+			summary: "Print the variables of a workspace or of a variable set.",
+			examples: []string{
+				"read -ws my-org/my-ws > vars.json",
+				"read -ws ws-xxxx -format tfvars > terraform.tfvars",
+				"read -varset varset-xxxx -format dotenv > aws.env",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "read from")
+				formatFlag(fs)
+			},
+			validate: requireTarget,
+			run:      read,
+		},
+		{
+			name:    "load",
+			summary: "Load the variables of a file into a workspace, a variable set or all the workspaces selected by name or tags.",
+			examples: []string{
+				"load -ws my-org/my-ws -file vars.json",
+				"load -ws my-org/my-ws -file terraform.tfvars -upsert -prune",
+				"load -org my-org -match 'app-*-prod' -tags aws -stop-on-error -file vars.json",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "load into")
+				fileFlags(fs)
+				fs.BoolVar(&upsert, "upsert", false, "Update the variables already defined instead of failing.")
+				fs.BoolVar(&prune, "prune", false, "Delete the variables not defined in the file. tfvars and .env files only delete the variables of their category.")
+				fs.BoolVar(&force, "force", false, "Do not ask for confirmation before deleting variables.")
+				fs.StringVar(&match, "match", "", "Load into every workspace of the organization whose name matches the glob pattern, eg: app-*-prod")
+				fs.StringVar(&tags, "tags", "", "Load into every workspace of the organization with all the comma separated tags.")
+				fs.BoolVar(&stopOnErr, "stop-on-error", false, "Stop loading into the matching workspaces at the first failure.")
+				workersFlag(fs, 1, "Number of variables written at the same time.")
+			},
+			validate: func() {
+				require(fileName != "", "file name required")
+
+				if bulk() {
+					require(org != "", "organization required")
+					require(!prune, "prune is not supported loading into many workspaces")
+					return
+				}
+
+				requireTarget()
+			},
+			run: save,
+		},
+		{
+			name:    "plan",
+			aliases: []string{"diff"},
+			summary: "Print the changes a load of the file would apply, without changing anything. Sensitive values are masked.",
+			examples: []string{
+				"plan -ws my-org/my-ws -file vars.json",
+				"diff -ws my-org/my-ws -file terraform.tfvars -prune",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "compare with")
+				fileFlags(fs)
+				fs.BoolVar(&prune, "prune", false, "Show the deletion of the variables not defined in the file. tfvars and .env files only delete the variables of their category.")
+			},
+			validate: func() {
+				require(fileName != "", "file name required")
+				requireTarget()
+			},
+			run: plan,
+		},
+		{
+			name:    "copy",
+			summary: "Copy the variables of a workspace into another workspace or a variable set, without writing them on disk.",
+			examples: []string{
+				"copy -from my-org/my-ws -ws my-org/new-ws",
+				"copy -from my-org/my-ws -varset varset-xxxx -category env",
+				"copy -from my-org/my-ws -ws my-org/new-ws -with-sensitive",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "copy into")
+				fs.StringVar(&source, "from", "", "Terraform cloud workspace to copy the variables from: id, org/name or name with -org.")
+				fs.StringVar(&category, "category", "", "Copy only the variables of the category [terraform|env]")
+				fs.BoolVar(&skipSens, "skip-sensitive", false, "Do not copy the sensitive variables. This is the default, unless -with-sensitive.")
+				fs.BoolVar(&withSens, "with-sensitive", false, "Create the sensitive variables missing in the destination with an empty value, to be set later: the api never returns their values. The ones already defined in the destination are skipped, with or without -upsert.")
+				fs.BoolVar(&upsert, "upsert", false, "Update the variables already defined instead of failing.")
+				workersFlag(fs, 1, "Number of variables written at the same time.")
+			},
+			validate: func() {
+				require(source != "", "source workspace required")
+				requireTarget()
+			},
+			run: copyVars,
+		},
+		{
+			name:    "validate",
+			summary: "Check the variables of a file against the variable declarations of the terraform module: missing, unknown, wrong type, failed validation and not sensitive variables.",
+			examples: []string{
+				"validate -module ./infra -file vars.json",
+				"validate -module ./infra -file terraform.tfvars",
+			},
+			flags: func(fs *flag.FlagSet) {
+				fileFlags(fs)
+				fs.StringVar(&module, "module", "", "Directory of the terraform module with the variable declarations.")
+			},
+			validate: func() {
+				require(fileName != "", "file name required")
+				require(module != "", "module directory required")
+			},
+			run: validate,
+		},
+		{
+			name:    "get",
+			summary: "Print the raw value of a variable, for use in shell pipelines.",
+			examples: []string{
+				"get -ws my-org/my-ws -key AWS_REGION -category env",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "read from")
+				fs.StringVar(&keys, "key", "", "Key of the variable.")
+				fs.StringVar(&category, "category", "", "Category of the variable, required if the key is defined in both [terraform|env]")
+			},
+			validate: func() {
+				require(keys != "", "key required")
+				requireTarget()
+			},
+			run: getVar,
+		},
+		{
+			name:    "set",
+			summary: "Create a variable or update the existing one.",
+			examples: []string{
+				`set -ws my-org/my-ws -key zones -value '["a", "b"]' -hcl`,
+				`set -ws my-org/my-ws -key DB_PASSWORD -value "$DB_PASSWORD" -sensitive -category env`,
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "save in")
+				fs.StringVar(&keys, "key", "", "Key of the variable.")
+				fs.StringVar(&value, "value", "", "Value of the variable.")
+				fs.StringVar(&category, "category", "", "Category of the variable, terraform by default [terraform|env]")
+				fs.BoolVar(&hcl, "hcl", false, "The value is an hcl expression, eg: '[\"a\", \"b\"]'")
+				fs.BoolVar(&sensitive, "sensitive", false, "The variable is sensitive, its value can not be read back.")
+			},
+			validate: func() {
+				require(keys != "" && isFlagSet("value"), "key and value required")
+				requireTarget()
+			},
+			run: setVar,
+		},
+		{
+			name:    "delete",
+			summary: "Delete the variables selected by key, glob pattern or regular expression.",
+			examples: []string{
+				"delete -ws my-org/my-ws -pattern 'feature_*' -category terraform -dry-run",
+				"delete -ws my-org/my-ws -key OLD_TOKEN,OLD_URL -category env",
+				"delete -ws my-org/my-ws -regex 'flag_(login|search)_v[0-9]+' -force",
+			},
+			api: true,
+			flags: func(fs *flag.FlagSet) {
+				targetFlags(fs, "delete from")
+				fs.StringVar(&keys, "key", "", "Comma separated keys of the variables to delete.")
+				fs.StringVar(&pattern, "pattern", "", "Delete the variables whose key matches the glob pattern, eg: FEATURE_*")
+				fs.StringVar(&regex, "regex", "", "Delete the variables whose whole key matches the regular expression, eg: 'feature_(a|b)'")
+				fs.StringVar(&category, "category", "", "Delete only the variables of the category [terraform|env]")
+				fs.BoolVar(&dryRun, "dry-run", false, "Print the variables that would be deleted without deleting them.")
+				fs.BoolVar(&force, "force", false, "Do not ask for confirmation before deleting variables.")
+				workersFlag(fs, 1, "Number of variables deleted at the same time.")
+			},
+			validate: func() {
+				require(keys != "" || pattern != "" || regex != "", "key, pattern or regex required")
+				requireTarget()
+			},
+			run: deleteVars,
+		},
+		{
+			name:     "varsets",
+			summary:  "Print the variable sets of the organization.",
+			examples: []string{"varsets -org my-org"},
+			api:      true,
+			validate: func() {
+				require(org != "", "organization required")
+			},
+			run: listVarSets,
+		},
+		{
+			name:     "attach",
+			summary:  "Apply a variable set to a workspace.",
+			examples: []string{"attach -varset varset-xxxx -ws my-org/my-ws"},
+			api:      true,
+			flags:    attachFlags,
+			validate: requireAttach,
+			run:      func() { attach(true) },
+		},
+		{
+			name:     "detach",
+			summary:  "Remove a variable set from a workspace.",
+			examples: []string{"detach -varset varset-xxxx -ws my-org/my-ws"},
+			api:      true,
+			flags:    attachFlags,
+			validate: requireAttach,
+			run:      func() { attach(false) },
+		},
+		{
+			name:     "export-org",
+			summary:  "Write the variables of every workspace of the organization in a file per workspace, in the workspaces subdirectory, plus an index.json file.",
+			examples: []string{"export-org -org my-org -dir ./backup -format tfvars -workers 8"},
+			api:      true,
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&dir, "dir", ".", "Directory where to write the index.json file and the workspaces subdirectory, with one file per workspace.")
+				formatFlag(fs)
+				workersFlag(fs, 8, "Number of workspaces read at the same time.")
+			},
+			validate: func() {
+				require(org != "", "organization required")
+				_, ok := extensions[format]
+				require(ok, "wrong format value.")
+			},
+			run: exportOrg,
+		},
+	}
+}
+
+// apiFlags defines the flags of the commands calling the api.
+func apiFlags(fs *flag.FlagSet) {
+	fs.StringVar(&host, "host", LookupEnvOrString("TF_HOSTNAME", tfcloud.DEFAULT_HOST), "Terraform cloud or enterprise hostname. If not defined it reads the env variable TF_HOSTNAME")
+	fs.StringVar(&org, "org", LookupEnvOrString("TF_ORGANIZATION", ""), "Terraform cloud organization of the workspaces referenced by name. If not defined it reads the env variable TF_ORGANIZATION")
+	fs.StringVar(&token, "token", LookupEnvOrString("TF_TOKEN", ""), "bearer token for authentication. If not defined it reads the env variable TF_TOKEN, the token of the profile or the terraform cli credentials: TF_TOKEN_<host>, .terraformrc, credentials.tfrc.json and the credentials helper")
+	fs.DurationVar(&timeout, "timeout", 0, "Maximum duration of the operation, eg: 30s or 5m. No limit by default.")
+	fs.BoolVar(&verbose, "verbose", false, "Print where the token was read from.")
+	fs.StringVar(&profile, "profile", LookupEnvOrString("TFCLOUDVARS_PROFILE", ""), "Profile of the configuration file with the default host, organization, format and token. If not defined it reads the env variable TFCLOUDVARS_PROFILE or the default_profile of the file")
+}
+
+// targetFlags defines the flags selecting the workspace or the variable set
+// the command acts on.
+func targetFlags(fs *flag.FlagSet, action string) {
+	fs.StringVar(&workspace, "ws", "", fmt.Sprintf("Terraform cloud workspace to %s: id (ws-xxxx), org/name or name with -org.", action))
+	fs.StringVar(&varset, "varset", "", fmt.Sprintf("Variable set id to %s instead of a workspace.", action))
+}
+
+func attachFlags(fs *flag.FlagSet) {
+	fs.StringVar(&workspace, "ws", "", "Terraform cloud workspace: id (ws-xxxx), org/name or name with -org.")
+	fs.StringVar(&varset, "varset", "", "Variable set id.")
+}
+
+func fileFlags(fs *flag.FlagSet) {
+	fs.StringVar(&fileName, "file", "", "json, .tfvars, .tfvars.json or .env file with the variables")
+	fs.StringVar(&category, "category", "", "Category of the variables of a tfvars file, terraform by default [terraform|env]")
+}
+
+func formatFlag(fs *flag.FlagSet) {
+	fs.StringVar(&format, "format", "json", "Output format [json|tfvars|dotenv]")
+}
+
+func workersFlag(fs *flag.FlagSet, value int, usage string) {
+	fs.IntVar(&workers, "workers", value, usage)
+}
+
+// require exits printing the usage of the command when ok is false.
+func require(ok bool, msg string) {
+
+	if !ok {
+		log.Println(fmt.Sprintf("[INFO] %s", msg))
+		Usage()
+		os.Exit(EXIT_USAGE)
+	}
+}
+
+func requireTarget() {
+	require(workspace != "" || varset != "", "workspace or variable set required")
+}
+
+func requireAttach() {
+	require(varset != "" && workspace != "", "variable set and workspace required")
+}
+
+// program is the name of the executable.
+func program() string {
+	return filepath.Base(os.Args[0])
+}
+
+// findCommand returns the command called name or with the alias name.
+func findCommand(name string) *command {
+
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+		for _, a := range c.aliases {
+			if a == name {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// flagSet returns the flags of the command.
+func (c *command) flagSet() *flag.FlagSet {
+
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+
+	if c.api {
+		apiFlags(fs)
+	}
+
+	if c.flags != nil {
+		c.flags(fs)
+	}
+
+	return fs
+}
+
+// usage returns the function printing the help of the command.
+func (c *command) usage(fs *flag.FlagSet) func() {
+
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags]\n\n%s\n", program(), c.name, c.summary)
+
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(os.Stderr, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+		}
+
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		fs.SetOutput(os.Stderr)
+		fs.PrintDefaults()
+
+		if len(c.examples) > 0 {
+			fmt.Fprintln(os.Stderr, "\nExamples:")
+
+			for _, e := range c.examples {
+				fmt.Fprintf(os.Stderr, "  %s %s\n", program(), e)
+			}
+		}
+	}
+}
+
+// parse parses the flags of the command. It fails on the flags of the
+// other commands and on unexpected arguments.
+func (c *command) parse(args []string) {
+
+	fs := c.flagSet()
+
+	// the errors are reported below, with the usage of the command.
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+
+	err := fs.Parse(args)
+
+	flags = fs
+	Usage = c.usage(fs)
+
+	switch {
+	case err == flag.ErrHelp:
+		Usage()
+		os.Exit(0)
+	case err != nil:
+		log.Println(fmt.Sprintf("[ERROR] %s", c.flagError(err)))
+		Usage()
+		os.Exit(EXIT_USAGE)
+	case fs.NArg() > 0:
+		log.Println(fmt.Sprintf("[ERROR] unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+		Usage()
+		os.Exit(EXIT_USAGE)
+	}
+}
+
+// flagError explains the parsing error err, telling apart the flags that
+// belong to other commands from the unknown ones.
+func (c *command) flagError(err error) string {
+
+	const undefined = "flag provided but not defined: -"
+
+	msg := err.Error()
+
+	if !strings.HasPrefix(msg, undefined) {
+		return msg
+	}
+
+	name := strings.TrimPrefix(msg, undefined)
+
+	if allFlags().Lookup(name) != nil {
+		return fmt.Sprintf("-%s does not apply to the %s command", name, c.name)
+	}
+
+	return fmt.Sprintf("unknown flag -%s", name)
+}
+
+// allFlags returns the flags of all the commands.
+// Defining the flags resets the variables to their default values.
+func allFlags() *flag.FlagSet {
+
+	all := flag.NewFlagSet(program(), flag.ContinueOnError)
+
+	for _, c := range commands {
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			if all.Lookup(f.Name) == nil {
+				all.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	}
+
+	return all
+}
+
+// help prints the commands.
+func help() {
+
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", program())
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", c.name, c.summary)
+	}
+
+	fmt.Fprintf(w, "  help\tPrint the help of a command.\n")
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "\nRun '%s help <command>' for the flags and the examples of a command.\n", program())
+}
+
+// legacy runs the deprecated form of the command line:
+// -do <command> followed by the flags of any command.
+func legacy(args []string) {
+
+	fs := allFlags()
+	fs.StringVar(&do, "do", "help", "Deprecated: the command to run.")
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+
+	err := fs.Parse(args)
+
+	flags = fs
+
+	switch {
+	case err == flag.ErrHelp:
+		help()
+		os.Exit(0)
+	case err != nil:
+		log.Println(fmt.Sprintf("[ERROR] %s", err.Error()))
+		help()
+		os.Exit(EXIT_USAGE)
+	}
+
+	c := findCommand(do)
+
+	if c == nil {
+		help()
+		return
+	}
+
+	log.Println(fmt.Sprintf("[WARN] -do is deprecated, use: %s %s [flags]", program(), c.name))
+
+	// the flags are applied again over the defaults of the command,
+	// eg: -workers, instead of the ones of the last command defining them.
+	c.flagSet()
+	fs.Parse(args)
+
+	Usage = help
+
+	run(c)
+}
@@ -1,3 +1,8 @@
 module github.com/uolter/cptfcvars
 
 go 1.15
+
+require (
+	github.com/hashicorp/hcl/v2 v2.10.0
+	github.com/zclconf/go-cty v1.8.0
+)
@@ -1,21 +1,68 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/uolter/cptfcvars/tfcloud"
 )
 
 var (
+	ctx       context.Context
+	client    *tfcloud.Client
+	flags     *flag.FlagSet
 	do        string
 	fileName  string
 	workspace string
+	org       string
+	host      string
+	varset    string
+	dir       string
+	workers   int
+	match     string
+	tags      string
+	stopOnErr bool
 	token     string
 	format    string
+	upsert    bool
+	prune     bool
+	force     bool
+	source    string
+	category  string
+	skipSens  bool
+	withSens  bool
+	timeout   time.Duration
+	keys      string
+	pattern   string
+	regex     string
+	dryRun    bool
+	value     string
+	hcl       bool
+	sensitive bool
+	profile   string
+	module    string
+	verbose   bool
+	// tokenSource describes where the token was found, for -verbose.
+	tokenSource string
+)
+
+// Exit codes of the operations.
+const (
+	EXIT_FAILURE = 1 // the operation failed, for every variable if many
+	EXIT_PARTIAL = 2 // the operation failed for some variables only
+	EXIT_AUTH    = 3 // the token is missing or rejected by the api
+	EXIT_USAGE   = 4 // the command line is not valid
 )
 
 func LookupEnvOrString(key string, defaultVal string) string {
@@ -25,116 +72,841 @@ func LookupEnvOrString(key string, defaultVal string) string {
 	return defaultVal
 }
 
-var Usage = func() {
-	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+// Usage prints the help of the command being run.
+var Usage = help
+
+func main() {
+
+	args := os.Args[1:]
+
+	if len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		legacy(args)
+		return
+	}
+
+	if len(args) == 0 || args[0] == "help" {
+		if len(args) > 1 && findCommand(args[1]) != nil {
+			findCommand(args[1]).parse([]string{"-h"})
+		}
+		help()
+		return
+	}
+
+	c := findCommand(args[0])
 
-	flag.PrintDefaults()
+	if c == nil {
+		log.Println(fmt.Sprintf("[ERROR] unknown command %s", args[0]))
+		help()
+		os.Exit(EXIT_USAGE)
+	}
+
+	c.parse(args[1:])
+
+	run(c)
 }
 
-func init() {
-	flag.StringVar(&do, "do", "help", "Operation: [read|load|help]")
-	flag.StringVar(&workspace, "ws", "", "Terraform cloud workspace id to read from or to save in.")
-	flag.StringVar(&fileName, "file", "", "json file with variables to load in a workspace")
-	flag.StringVar(&token, "token", LookupEnvOrString("TF_TOKEN", ""), "bearer token for authenticatio. If not defined it reads the env variable TF_TOKEN or the credeintial storage file: credentials.tfrc.json")
-	flag.StringVar(&format, "format", "json", "Output format [json|tfvars]")
+// run validates the flags of the command and runs it.
+func run(c *command) {
+
+	do = c.name
+
+	if c.api {
+		applyProfile()
+	}
 
-	flag.Parse()
+	if c.validate != nil {
+		c.validate()
+	}
+
+	if c.api {
+		cancel := connect()
+		defer cancel()
+	}
+
+	c.run()
 }
 
-func main() {
+// configFile returns the path of the configuration file with the profiles:
+// the env variable TFCLOUDVARS_CONFIG or ~/.config/tfcloudvars/config.hcl
+func configFile() string {
 
-	if workspace == "" {
-		log.Println("[INFO] workspace required")
-		Usage()
-		os.Exit(0)
+	if f, ok := os.LookupEnv("TFCLOUDVARS_CONFIG"); ok {
+		return f
 	}
 
+	dirname, err := os.UserHomeDir()
+
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dirname, ".config", "tfcloudvars", "config.hcl")
+}
+
+// applyProfile sets the host, organization, format and token of the profile
+// that are not set by a flag or an env variable. The precedence is:
+// flags, env variables, profile and the defaults.
+func applyProfile() {
+
+	f := configFile()
+
+	if _, err := os.Stat(f); err != nil {
+		// without a configuration file there is no profile to apply.
+		if profile != "" {
+			fatal(fmt.Errorf("profile %q not found: %s", profile, err))
+		}
+		return
+	}
+
+	c := tfcloud.ToolConfig{}
+
+	if err := c.Read(f); err != nil {
+		fatal(err)
+	}
+
+	p, err := c.Profile(profile)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	set := func(dst *string, v string, name string, env string) {
+		if _, ok := os.LookupEnv(env); v == "" || ok || isFlagSet(name) {
+			return
+		}
+		*dst = v
+	}
+
+	set(&host, p.Host, "host", "TF_HOSTNAME")
+	set(&org, p.Organization, "org", "TF_ORGANIZATION")
+	set(&format, p.Format, "format", "")
+
 	if token == "" {
+		if token, _, err = p.ReadToken(); err != nil {
+			fatal(err)
+		}
+		if token != "" {
+			tokenSource = fmt.Sprintf("profile %q of %s", p.Name, f)
+		}
+	}
+}
 
-		c := tfcloud.TfConfig{}
-		dirname, err := os.UserHomeDir()
+// connect builds the api client, looking up the token if not set, and
+// resolves the workspaces. The returned function cancels the context.
+func connect() context.CancelFunc {
+
+	switch {
+	case isFlagSet("token"):
+		tokenSource = "flag -token"
+	case token != "" && tokenSource == "":
+		tokenSource = "env variable TF_TOKEN"
+	case token == "":
+		c := tfcloud.DefaultCredentialsChain()
+		var err error
+
+		if token, tokenSource, _, err = c.Token(host); err != nil {
+			fatal(err)
+		}
 
-		if err != nil {
-			log.Fatal(err)
+		if token == "" {
+			log.Println(fmt.Sprintf("[ERROR] token required for %s", host))
 			Usage()
-			os.Exit(0)
+			os.Exit(EXIT_AUTH)
 		}
+	}
 
-		err = c.Read(filepath.Join(dirname, ".terraform.d", "credentials.tfrc.json"))
+	if verbose {
+		log.Println(fmt.Sprintf("[INFO] token of %s read from %s", host, tokenSource))
+	}
 
-		if err == nil {
-			token = c.Credentials.App_terraform_io.Token
-		} else {
-			log.Println("[INFO] token required")
-			Usage()
-			os.Exit(0)
+	client = tfcloud.NewClient(tfcloud.WithHost(host), tfcloud.WithToken(token), tfcloud.WithWorkers(workers))
+
+	var cancel context.CancelFunc
+	ctx, cancel = newContext()
+
+	if workspace != "" {
+		workspace = resolve(workspace)
+	}
+
+	if source != "" {
+		source = resolve(source)
+	}
+
+	return cancel
+}
+
+// newContext returns the context of the api calls: it is cancelled
+// after the timeout, if any, or when the user hits ctrl-c.
+// A second ctrl-c terminates the program.
+func newContext() (context.Context, context.CancelFunc) {
+
+	var c context.Context
+	var cancel context.CancelFunc
+
+	if timeout > 0 {
+		c, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		c, cancel = context.WithCancel(context.Background())
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func() {
+		select {
+		case <-interrupt:
+			log.Println("[INFO] interrupted: cancelling the requests")
+			cancel()
+		case <-c.Done():
 		}
+		signal.Stop(interrupt)
+	}()
+
+	return c, cancel
+}
+
+// checkCancelled exits when the operation has been cancelled by a timeout
+// or by the user, reporting the variables already applied.
+func checkCancelled(r tfcloud.Result, err error) {
+
+	if err == nil || ctx.Err() == nil {
+		return
 	}
 
-	switch do {
-	case "read":
-		read()
-	case "load":
-		save()
-	default:
-		Usage()
+	log.Println(fmt.Sprintf("[ERROR] operation cancelled: %s", err.Error()))
+
+	applied := r.Applied()
+
+	if len(applied) == 0 {
+		log.Println("[INFO] no variable applied")
+	} else {
+		log.Println(fmt.Sprintf("[INFO] applied before the cancellation: %s", strings.Join(applied, ", ")))
 	}
+
+	os.Exit(EXIT_FAILURE)
 }
 
-func read() {
+// fatal logs the error and exits, with EXIT_AUTH when the token is rejected.
+func fatal(err error) {
 
-	t := tfcloud.TerraformVars{}
+	log.Println(fmt.Sprintf("[ERROR] %s", err.Error()))
+
+	if tfcloud.IsUnauthorized(err) {
+		os.Exit(EXIT_AUTH)
+	}
+
+	os.Exit(EXIT_FAILURE)
+}
+
+// keyErrors reports whether err only collects the errors of single variables,
+// reported by the result of the operation.
+func keyErrors(err error) bool {
+	_, ok := err.(tfcloud.KeyErrors)
+	return ok
+}
+
+// exitCode returns the exit code of an operation on many variables:
+// EXIT_AUTH if the token has been rejected, EXIT_FAILURE if every variable
+// failed and EXIT_PARTIAL if only some of them did.
+func exitCode(r tfcloud.Result) int {
+
+	failed := r.Count(tfcloud.StatusFailed)
+
+	if failed == 0 {
+		return 0
+	}
+
+	for _, k := range r.Keys {
+		if tfcloud.IsUnauthorized(k.Err) {
+			return EXIT_AUTH
+		}
+	}
+
+	if failed == len(r.Keys) {
+		return EXIT_FAILURE
+	}
+
+	return EXIT_PARTIAL
+}
+
+// resolve returns the id of the workspace ws, looking it up by name when needed.
+func resolve(ws string) string {
+
+	id, err := client.ResolveWorkspace(ctx, ws, org)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	return id
+}
+
+// getVars reads the variables of the variable set, if set, or of the workspace.
+func getVars() tfcloud.TerraformVars {
+
+	var t tfcloud.TerraformVars
+	var err error
+
+	if varset != "" {
+		t, err = client.GetVarSetVars(ctx, varset)
+	} else {
+		t, err = client.GetVars(ctx, workspace)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+
+	return t
+}
+
+// listVarSets prints the variable sets of the organization.
+func listVarSets() {
+
+	s, err := client.GetVarSets(ctx, org)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	j, err := s.Json(true)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	fmt.Println(j)
+}
+
+// attach applies the variable set to the workspace or removes it.
+func attach(on bool) {
 
 	var err error
-	var j string
 
-	if err := t.Get(workspace, token); err != nil {
-		log.Printf("[ERROR]: %t", err)
-		os.Exit(1)
+	if on {
+		err = client.AttachVarSet(ctx, varset, []string{workspace})
+	} else {
+		err = client.DetachVarSet(ctx, varset, []string{workspace})
 	}
 
+	if err != nil {
+		fatal(err)
+	}
+
+	log.Println(fmt.Sprintf("[INFO] %s %sed", varset, do))
+}
+
+// render formats the variables in the output format.
+func render(t *tfcloud.TerraformVars) (string, error) {
+
 	switch format {
 	case "json":
-		j, err = t.Json(true)
+		return t.Json(true)
 	case "tfvars":
-		j, err = t.ToTfVars(true)
+		return t.ToTfVars(true)
+	case "dotenv":
+		return t.ToDotEnv()
+	}
+
+	return "", fmt.Errorf("wrong format value %s", format)
+}
+
+// extensions are the file extensions of the output formats.
+var extensions = map[string]string{
+	"json":   ".json",
+	"tfvars": ".tfvars",
+	"dotenv": ".env",
+}
+
+// indexEntry describes a workspace exported by export-org.
+type indexEntry struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	File      string `json:"file,omitempty"`
+	Variables int    `json:"variables"`
+	Error     string `json:"error,omitempty"`
+}
+
+// exportOrg writes the variables of every workspace of the organization
+// in a file named after the workspace in the workspaces subdirectory, so
+// that no workspace name collides with the index.json file.
+func exportOrg() {
+
+	ext := extensions[format]
+
+	w, err := client.GetWorkspaces(ctx, org)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "workspaces"), 0700); err != nil {
+		fatal(err)
+	}
+
+	index := []indexEntry{}
+	failed := 0
+
+	for _, v := range client.GetWorkspacesVars(ctx, &w, workers) {
+		e := indexEntry{ID: v.Workspace.ID, Name: v.Workspace.Attributes.Name, Variables: len(v.Vars.Data)}
+
+		err := v.Err
+
+		if err == nil {
+			var data string
+
+			if data, err = render(&v.Vars); err == nil {
+				e.File = filepath.Join("workspaces", e.Name+ext)
+				err = ioutil.WriteFile(filepath.Join(dir, e.File), []byte(data), 0600)
+			}
+		}
+
+		if err != nil {
+			failed++
+			e.Error = err.Error()
+			log.Println(fmt.Sprintf("[ERROR] %s %s", e.Name, e.Error))
+		} else {
+			log.Println(fmt.Sprintf("%s exported.", e.Name))
+		}
+
+		index = append(index, e)
+	}
+
+	j, err := json.MarshalIndent(index, "", "  ")
+
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "index.json"), j, 0600)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+
+	log.Println(fmt.Sprintf("[INFO] exported: %d failed: %d", len(index)-failed, failed))
+
+	switch {
+	case failed == 0:
+	case failed == len(index):
+		os.Exit(EXIT_FAILURE)
 	default:
-		log.Println("[INFO] wrong format value.")
-		Usage()
+		os.Exit(EXIT_PARTIAL)
 	}
+}
+
+func read() {
+
+	t := getVars()
+
+	j, err := render(&t)
 
 	if err != nil {
-		log.Printf("[ERROR]: %t", err)
-		os.Exit(1)
+		fatal(err)
 	}
 	fmt.Println(j)
 
 }
 
+// loadFile reads the variables of the input file. The variables of tfvars
+// files are loaded in the category flag, terraform by default.
+func loadFile() tfcloud.TerraformVars {
+
+	t := tfcloud.TerraformVars{}
+
+	var err error
+
+	if tfcloud.IsTfVars(fileName) && category != "" {
+		err = t.LoadTfVars(fileName, category)
+	} else {
+		err = t.Load(fileName)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+
+	return t
+}
+
 func save() {
 
-	if fileName == "" {
-		log.Println("[INFO] file name required")
-		Usage()
-		os.Exit(0)
+	t := loadFile()
+
+	if bulk() {
+		bulkLoad(&t)
+		return
 	}
 
-	t := tfcloud.TerraformVars{}
+	log.Println("Load into workspace")
+
+	r := apply(&t)
+
+	if prune {
+		p := pruneVars(&t)
+		r.Keys = append(r.Keys, p.Keys...)
+	}
+
+	if code := exitCode(r); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// bulk reports whether the load targets the workspaces selected by name or tags.
+func bulk() bool {
+	return do == "load" && (match != "" || tags != "")
+}
+
+// bulkLoad upserts the variables in every workspace of the organization
+// selected by name pattern and tags, then prints a summary table.
+func bulkLoad(t *tfcloud.TerraformVars) {
 
-	err := t.Load(fileName)
+	all, err := client.GetWorkspaces(ctx, org)
 
 	if err != nil {
-		log.Println(fmt.Sprintf("[ERROR] %s", err.Error()))
-		os.Exit(1)
+		fatal(err)
 	}
 
-	log.Println("Load into workspace")
+	var tagList []string
+
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+
+	selected, err := all.Select(match, tagList)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(selected.Data) == 0 {
+		log.Println("[INFO] no workspace matches")
+		return
+	}
+
+	summary := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(summary, "WORKSPACE\tCREATED\tUPDATED\tUNCHANGED\tFAILED\tERROR")
+
+	// total collects the variables of all the workspaces, a workspace
+	// that could not be read counts as a failed variable.
+	total := tfcloud.Result{}
+
+	for _, w := range selected.Data {
+		log.Println(fmt.Sprintf("Load into workspace %s", w.Attributes.Name))
+
+		r, err := client.UpsertVars(ctx, w.ID, t)
+
+		msg := ""
+
+		if err != nil && !keyErrors(err) {
+			msg = err.Error()
+			r.Keys = append(r.Keys, tfcloud.KeyResult{Key: w.Attributes.Name, Status: tfcloud.StatusFailed, Err: err})
+		}
+
+		fmt.Fprintf(summary, "%s\t%d\t%d\t%d\t%d\t%s\n", w.Attributes.Name,
+			r.Count(tfcloud.StatusCreated), r.Count(tfcloud.StatusUpdated),
+			r.Count(tfcloud.StatusUnchanged), r.Count(tfcloud.StatusFailed), msg)
+
+		total.Keys = append(total.Keys, r.Keys...)
+
+		if err != nil && (stopOnErr || ctx.Err() != nil) {
+			break
+		}
+	}
+
+	summary.Flush()
+
+	if code := exitCode(total); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// copyVars copies the variables from the source workspace into the workspace
+// without writing them on disk.
+func copyVars() {
+
+	src, err := client.GetVars(ctx, source)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	// the api never returns the sensitive values: they can only be created
+	// empty, on request, and the ones already in the destination are skipped.
+	var dest tfcloud.TerraformVars
+
+	if withSens && !skipSens {
+		dest = getVars()
+	}
+
+	t := src.Filter(func(d tfcloud.Data) bool {
+		if category != "" && d.Category != category {
+			return false
+		}
+		if !d.Sensitive {
+			return true
+		}
+		if skipSens || !withSens {
+			log.Println(fmt.Sprintf("[WARN] %s is sensitive: skipped, its value can not be read.", d.Key))
+			return false
+		}
+		if _, found := dest.Find(d.Key, d.Category); found {
+			log.Println(fmt.Sprintf("[WARN] %s is sensitive: skipped, already defined in the destination.", d.Key))
+			return false
+		}
+		log.Println(fmt.Sprintf("[WARN] %s is sensitive: it is created with an empty value.", d.Key))
+		return true
+	})
+
+	log.Println(fmt.Sprintf("Copy %d variables into workspace", len(t.Data)))
+
+	r := apply(&t)
+
+	if code := exitCode(r); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// apply creates the variables in the variable set or in the workspace,
+// updating the existing ones in upsert mode.
+func apply(t *tfcloud.TerraformVars) tfcloud.Result {
+
+	var r tfcloud.Result
+	var err error
+
+	switch {
+	case varset != "" && upsert:
+		r, err = client.UpsertVarSetVars(ctx, varset, t)
+	case varset != "":
+		r, err = client.PostVarSetVars(ctx, varset, t)
+	case upsert:
+		r, err = client.UpsertVars(ctx, workspace, t)
+	default:
+		r, err = client.PostVars(ctx, workspace, t)
+	}
+
+	checkCancelled(r, err)
+
+	if err != nil && !keyErrors(err) {
+		fatal(err)
+	}
+
+	log.Println(fmt.Sprintf("[INFO] created: %d updated: %d unchanged: %d failed: %d",
+		r.Count(tfcloud.StatusCreated), r.Count(tfcloud.StatusUpdated),
+		r.Count(tfcloud.StatusUnchanged), r.Count(tfcloud.StatusFailed)))
+
+	return r
+}
+
+// plan prints the changes a load of the file would apply to the workspace.
+func plan() {
+
+	t := loadFile()
+
+	current := getVars()
+
+	p := t.Plan(&current, prune, pruneCategory())
+
+	fmt.Print(p.String())
+}
+
+// validate checks the variables of the file against the module declarations.
+func validate() {
+
+	t := loadFile()
+
+	m, err := tfcloud.LoadModule(module)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	e := m.Validate(&t)
+
+	for _, v := range e {
+		fmt.Println(v)
+	}
+
+	if len(e) > 0 {
+		log.Println(fmt.Sprintf("[ERROR] %d variables not valid for the module %s", len(e), module))
+		os.Exit(EXIT_FAILURE)
+	}
+
+	log.Println(fmt.Sprintf("[INFO] variables valid for the module %s", module))
+}
+
+// pruneCategory returns the only category the file can hold, or an empty
+// string for both: a prune never deletes the variables of the other category.
+func pruneCategory() string {
+
+	if tfcloud.IsTfVars(fileName) && category != "" {
+		return category
+	}
+
+	return tfcloud.FileCategory(fileName)
+}
+
+// pruneVars deletes the workspace or variable set variables not defined in t,
+// in the categories the file can hold.
+func pruneVars(t *tfcloud.TerraformVars) tfcloud.Result {
+
+	current := getVars()
+
+	stale := tfcloud.TerraformVars{Data: t.Stale(&current, pruneCategory())}
+
+	if len(stale.Data) == 0 {
+		log.Println("[INFO] nothing to prune")
+		return tfcloud.Result{}
+	}
+
+	return remove(&stale)
+}
+
+// getVar prints the raw value of the variable, for use in shell pipelines.
+func getVar() {
+
+	var d tfcloud.Data
+	var err error
+
+	if varset != "" {
+		d, err = client.GetVarSetVar(ctx, varset, keys, category)
+	} else {
+		d, err = client.GetVar(ctx, workspace, keys, category)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if d.Sensitive {
+		log.Println(fmt.Sprintf("[ERROR] %s is sensitive: its value can not be read", d.Key))
+		os.Exit(EXIT_FAILURE)
+	}
+
+	fmt.Println(d.Value)
+}
+
+// setVar creates the variable or updates the existing one.
+func setVar() {
+
+	a := tfcloud.Attributes{
+		Key:       keys,
+		Value:     value,
+		Category:  category,
+		Hcl:       hcl,
+		Sensitive: sensitive,
+	}
+
+	if a.Category == "" {
+		a.Category = "terraform"
+	}
+
+	var err error
+
+	if varset != "" {
+		_, err = client.SetVarSetVar(ctx, varset, a)
+	} else {
+		_, err = client.SetVar(ctx, workspace, a)
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// isFlagSet reports whether the flag has been set on the command line.
+func isFlagSet(name string) bool {
+
+	set := false
+
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+
+	return set
+}
+
+// deleteVars deletes the workspace or variable set variables selected
+// by key, glob pattern or regular expression, and category.
+func deleteVars() {
+
+	var keyList []string
+
+	if keys != "" {
+		keyList = strings.Split(keys, ",")
+	}
+
+	current := getVars()
+
+	selected, err := current.Select(keyList, pattern, regex, category)
+
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(selected.Data) == 0 {
+		log.Println("[INFO] no variable matches")
+		return
+	}
+
+	if dryRun {
+		for _, d := range selected.Data {
+			fmt.Printf("  - %s (%s)\n", d.Key, d.Category)
+		}
+		log.Println(fmt.Sprintf("[INFO] %d variables would be deleted", len(selected.Data)))
+		return
+	}
+
+	r := remove(&selected)
+
+	if code := exitCode(r); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// remove lists the variables of t and deletes them from the workspace
+// or the variable set, after the confirmation of the user unless forced.
+func remove(t *tfcloud.TerraformVars) (r tfcloud.Result) {
+
+	for _, d := range t.Data {
+		fmt.Printf("  - %s (%s)\n", d.Key, d.Category)
+	}
+
+	target := workspace
+
+	if varset != "" {
+		target = varset
+	}
+
+	if !force && !confirm(fmt.Sprintf("Delete %d variables from %s?", len(t.Data), target)) {
+		log.Println("[INFO] delete cancelled")
+		return r
+	}
+
+	var err error
+
+	if varset != "" {
+		r, err = client.DeleteVarSetVars(ctx, varset, t)
+	} else {
+		r, err = client.DeleteVars(ctx, workspace, t)
+	}
+
+	checkCancelled(r, err)
+
+	log.Println(fmt.Sprintf("[INFO] deleted: %d failed: %d",
+		r.Count(tfcloud.StatusDeleted), r.Count(tfcloud.StatusFailed)))
+
+	return r
+}
+
+// confirm asks the user a yes/no question on the standard input.
+func confirm(question string) bool {
+
+	fmt.Printf("%s Only 'yes' will be accepted: ", question)
 
-	err = t.Post(workspace, token)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
 
 	if err != nil {
-		log.Println(fmt.Sprintf("[ERROR] %s", err.Error()))
-		os.Exit(1)
+		return false
 	}
 
+	return strings.TrimSpace(answer) == "yes"
 }
@@ -0,0 +1,89 @@
+package tfcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// newRequest builds a request to the url u with the client headers.
+// The request is cancelled when ctx is done.
+func (c *Client) newRequest(ctx context.Context, method string, u string, body io.Reader) (*http.Request, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(BEARER_TOKEN, c.token))
+	req.Header.Set("Content-Type", CONTENT_TYPE)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	return req, nil
+}
+
+// get requests u and returns the http status code and the response body.
+func (c *Client) get(ctx context.Context, u string) (status int, body []byte, err error) {
+
+	req, err := c.newRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return resp.StatusCode, nil, err
+	}
+
+	return resp.StatusCode, buf.Bytes(), nil
+}
+
+// send requests u with the json encoding of payload as body, if not nil.
+// It fails when the response status code is not the expected one.
+func (c *Client) send(ctx context.Context, method string, u string, payload interface{}, expected int) (err error) {
+	return c.sendFor(ctx, method, u, payload, expected, nil)
+}
+
+// sendFor works as send and decodes the json response body in out, if not nil.
+func (c *Client) sendFor(ctx context.Context, method string, u string, payload interface{}, expected int, out interface{}) (err error) {
+
+	var body io.Reader
+
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	req, err := c.newRequest(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, expected); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
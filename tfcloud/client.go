@@ -0,0 +1,135 @@
+package tfcloud
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const USER_AGENT = "tfcloudvars"
+
+// Client is a Terraform Cloud or Enterprise api client. Its settings are
+// fixed when it is built, so it is safe for concurrent use.
+type Client struct {
+	baseURL   string
+	token     string
+	http      HTTPClient
+	userAgent string
+	logger    *log.Logger
+	retry     *RetryPolicy
+	workers   int
+}
+
+// Option configures a Client built by NewClient.
+type Option func(c *Client)
+
+// WithBaseURL sets the api base url, eg: https://tfe.example.com
+func WithBaseURL(u string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithHost sets the hostname of the Terraform Cloud or Enterprise api.
+func WithHost(host string) Option {
+	return WithBaseURL("https://" + host)
+}
+
+// WithToken sets the bearer token.
+func WithToken(t string) Option {
+	return func(c *Client) {
+		c.token = t
+	}
+}
+
+// WithHTTPClient sets the client sending the http requests.
+func WithHTTPClient(h HTTPClient) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithLogger sets the logger of the per variable messages.
+// The standard logger is used by default.
+func WithLogger(l *log.Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the requests.
+// A policy with MaxRetries 0 disables the retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+// WithWorkers sets the number of variables written at the same time
+// by the operations on many variables. 1 by default.
+func WithWorkers(n int) Option {
+	return func(c *Client) {
+		c.workers = n
+	}
+}
+
+// NewClient builds a client for app.terraform.io with the default retry
+// policy, changed by the options.
+func NewClient(opts ...Option) *Client {
+
+	p := DefaultRetryPolicy
+
+	c := &Client{
+		baseURL:   "https://" + DEFAULT_HOST,
+		http:      &http.Client{},
+		userAgent: USER_AGENT,
+		retry:     &p,
+		workers:   1,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.retry.MaxRetries > 0 {
+		c.http = &RetryClient{Client: c.http, RetryPolicy: *c.retry}
+	}
+
+	return c
+}
+
+// defaultClient is the client of the package level functions: it uses
+// Host and DefaultHTTPClient, that already retries the requests.
+// t is the bearer token
+func defaultClient(t string) *Client {
+	return &Client{
+		baseURL:   "https://" + Host,
+		token:     t,
+		http:      DefaultHTTPClient,
+		userAgent: USER_AGENT,
+	}
+}
+
+// url returns the url of the api path.
+func (c *Client) url(path string, a ...interface{}) string {
+	return c.baseURL + fmt.Sprintf(path, a...)
+}
+
+// log writes a message on the client logger.
+func (c *Client) log(msg string) {
+
+	if c.logger != nil {
+		c.logger.Println(msg)
+		return
+	}
+
+	log.Println(msg)
+}
@@ -0,0 +1,508 @@
+package tfcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+func TestClientOptions(t *testing.T) {
+
+	var requests []*http.Request
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests = append(requests, req)
+
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}}
+
+	var logs bytes.Buffer
+
+	c := NewClient(
+		WithHost("tfe.example.com"),
+		WithToken("this.is.a.test"),
+		WithHTTPClient(m),
+		WithUserAgent("test-agent"),
+		WithLogger(log.New(&logs, "", 0)),
+		WithRetryPolicy(RetryPolicy{}),
+	)
+
+	v := TerraformVars{}
+	v.Data = append(v.Data, Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}})
+
+	if _, err := c.PostVars(context.Background(), "ws-test", &v); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	if len(requests) != 1 {
+		t.Log(fmt.Printf("error expected 1 request actual %d", len(requests)))
+		t.FailNow()
+	}
+
+	req := requests[0]
+
+	expected := "https://tfe.example.com/api/v2/workspaces/ws-test/vars"
+
+	if expected != req.URL.String() {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, req.URL.String()))
+		t.Fail()
+	}
+
+	if req.Header.Get("Authorization") != "Bearer this.is.a.test" || req.Header.Get("User-Agent") != "test-agent" {
+		t.Log(fmt.Printf("error unexpected headers %v", req.Header))
+		t.Fail()
+	}
+
+	if strings.TrimSpace(logs.String()) != "name created." {
+		t.Log(fmt.Printf("error unexpected log %s", logs.String()))
+		t.Fail()
+	}
+
+	if _, ok := c.http.(*RetryClient); ok {
+		t.Log("error expected the retries to be disabled")
+		t.Fail()
+	}
+}
+
+func TestNewClientDefaults(t *testing.T) {
+
+	c := NewClient()
+
+	expected := "https://app.terraform.io/api/v2/workspaces/ws-test/vars"
+	actual := c.url(TF_CLOUD_URL, "ws-test")
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+
+	r, ok := c.http.(*RetryClient)
+
+	if !ok || r.RetryPolicy != DefaultRetryPolicy {
+		t.Log("error expected the default retry policy")
+		t.Fail()
+	}
+}
+
+func TestUpsertCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests []*http.Request
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests = append(requests, req)
+
+		body := "{}"
+
+		if req.Method == "GET" {
+			body = `{"data":[]}`
+		} else {
+			// the first variable is applied, then the user hits ctrl-c.
+			cancel()
+		}
+
+		return &http.Response{
+			StatusCode: map[string]int{"GET": 200, "POST": 201}[req.Method],
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}}
+
+	c := NewClient(WithHTTPClient(m), WithLogger(log.New(ioutil.Discard, "", 0)))
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "first", Value: "1"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "second", Value: "2"}},
+	)
+
+	r, err := c.UpsertVars(ctx, "ws-test", &v)
+
+	if err != context.Canceled {
+		t.Log(fmt.Printf("error expected %v actual %v", context.Canceled, err))
+		t.Fail()
+	}
+
+	if len(r.Keys) != 1 || r.Keys[0].Key != "first" || r.Keys[0].Status != StatusCreated {
+		t.Log(fmt.Printf("error expected only the first key applied actual %v", r.Keys))
+		t.Fail()
+	}
+
+	if len(requests) != 2 || requests[1].Context() != ctx {
+		t.Log(fmt.Printf("error expected 2 requests with the context actual %d", len(requests)))
+		t.Fail()
+	}
+}
+
+func TestCreateWorkers(t *testing.T) {
+
+	var mu sync.Mutex
+	running, max := 0, 0
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		p := Payload{}
+		b, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(b, &p)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		status := 201
+
+		if strings.HasPrefix(p.Data.Attributes.Key, "bad") {
+			status = 422
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}}
+
+	var logs bytes.Buffer
+
+	c := NewClient(WithHTTPClient(m), WithWorkers(4), WithLogger(log.New(&logs, "", 0)))
+
+	v := TerraformVars{}
+	expected := []string{}
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		if i%5 == 0 {
+			key = fmt.Sprintf("bad%02d", i)
+		}
+		expected = append(expected, key)
+		v.Data = append(v.Data, Data{Attributes: Attributes{Category: "terraform", Key: key, Value: "v"}})
+	}
+
+	_, err := c.PostVars(context.Background(), "ws-test", &v)
+
+	// the log is in the order of the keys.
+	sort.Strings(expected)
+
+	e, ok := err.(KeyErrors)
+
+	if !ok || len(e) != 4 || e[0].Key != "bad00" || e[3].Key != "bad15" {
+		t.Log(fmt.Printf("error expected 4 failed keys actual %v", err))
+		t.Fail()
+	}
+
+	if max > 4 {
+		t.Log(fmt.Printf("error expected at most 4 requests at the same time actual %d", max))
+		t.Fail()
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+
+	if len(lines) != len(expected) {
+		t.Log(fmt.Printf("error expected %d log lines actual %d", len(expected), len(lines)))
+		t.FailNow()
+	}
+
+	for i, key := range expected {
+		if !strings.HasPrefix(lines[i], key+" ") {
+			t.Log(fmt.Printf("error expected %s actual %s", key, lines[i]))
+			t.Fail()
+		}
+	}
+}
+
+func TestSetVar(t *testing.T) {
+
+	j := `{
+		"data": [
+		  {
+			"id": "var-region",
+			"type": "vars",
+			"attributes": {
+			  "key": "region",
+			  "value": "eu-west-1",
+			  "sensitive": false,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  }
+		]
+	  }`
+
+	var requests []string
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests = append(requests, req.Method+" "+req.URL.Path)
+
+		status, body := 200, j
+
+		if req.Method == "POST" {
+			status, body = 201, `{"data": {"id": "var-zones", "type": "vars"}}`
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}}
+
+	c := NewClient(WithHTTPClient(m), WithLogger(log.New(ioutil.Discard, "", 0)))
+
+	k, err := c.SetVar(context.Background(), "ws-test", Attributes{Category: "terraform", Key: "region", Value: "eu-south-1"})
+
+	if err != nil || k.Status != StatusUpdated || k.ID != "var-region" {
+		t.Log(fmt.Printf("error expected region updated actual %v %v", k, err))
+		t.Fail()
+	}
+
+	k, err = c.SetVar(context.Background(), "ws-test", Attributes{Category: "terraform", Key: "zones", Value: `["a", "b"]`, Hcl: true})
+
+	if err != nil || k.Status != StatusCreated || k.ID != "var-zones" {
+		t.Log(fmt.Printf("error expected zones created actual %v %v", k, err))
+		t.Fail()
+	}
+
+	expected := fmt.Sprint([]string{
+		"GET /api/v2/workspaces/ws-test/vars",
+		"PATCH /api/v2/workspaces/ws-test/vars/var-region",
+		"GET /api/v2/workspaces/ws-test/vars",
+		"POST /api/v2/workspaces/ws-test/vars",
+	})
+
+	if expected != fmt.Sprint(requests) {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, requests))
+		t.Fail()
+	}
+
+	requests = nil
+
+	if _, err := c.SetVar(context.Background(), "ws-test", Attributes{Category: "terraform", Key: "zones", Value: `["a"`, Hcl: true}); err == nil || len(requests) != 0 {
+		t.Log("error expected an invalid hcl value to fail before any request")
+		t.Fail()
+	}
+}
+
+func TestReadUpsertSensitive(t *testing.T) {
+
+	// the api never returns the sensitive values.
+	j := `{
+		"data": [
+		  {
+			"id": "var-1",
+			"type": "vars",
+			"attributes": {
+			  "key": "password",
+			  "value": null,
+			  "sensitive": true,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  },
+		  {
+			"id": "var-2",
+			"type": "vars",
+			"attributes": {
+			  "key": "region",
+			  "value": "eu-west-1",
+			  "sensitive": false,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  }
+		]
+	  }`
+
+	var requests []string
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests = append(requests, req.Method+" "+req.URL.Path)
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+		}, nil
+	}}
+
+	c := NewClient(WithHTTPClient(m), WithLogger(log.New(ioutil.Discard, "", 0)))
+
+	v, err := c.GetVars(context.Background(), "ws-test")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	r, err := c.UpsertVars(context.Background(), "ws-test", &v)
+
+	if err != nil || r.Count(StatusUnchanged) != 2 {
+		t.Log(fmt.Printf("error expected 2 unchanged variables actual %v %v", r.Keys, err))
+		t.Fail()
+	}
+
+	if len(requests) != 2 {
+		t.Log(fmt.Printf("error expected only the GET requests actual %v", requests))
+		t.Fail()
+	}
+}
+
+// chanWriter sends every log line on the channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestEachLogsInOrder(t *testing.T) {
+
+	logs := make(chanWriter, 10)
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		p := Payload{}
+		b, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(b, &p)
+
+		// b is written only after a is logged: the log does not wait for all the variables.
+		if p.Data.Attributes.Key == "b" {
+			select {
+			case line := <-logs:
+				if line != "a created.\n" {
+					t.Log(fmt.Printf("error expected a logged first actual %s", line))
+					t.Fail()
+				}
+			case <-time.After(time.Second):
+				t.Log("error expected a logged before b is written")
+				t.Fail()
+			}
+		}
+
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}}
+
+	c := NewClient(WithHTTPClient(m), WithWorkers(2), WithLogger(log.New(logs, "", 0)))
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "b", Value: "2"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "a", Value: "1"}},
+	)
+
+	r, err := c.PostVars(context.Background(), "ws-test", &v)
+
+	if err != nil || len(r.Keys) != 2 || r.Keys[0].Key != "a" || r.Keys[1].Key != "b" {
+		t.Log(fmt.Printf("error expected a and b created in order actual %v %v", r.Keys, err))
+		t.Fail()
+	}
+}
+
+func TestReadTfVarsUpsertSensitive(t *testing.T) {
+
+	j := `{
+		"data": [
+		  {
+			"id": "var-1",
+			"type": "vars",
+			"attributes": {
+			  "key": "db_password",
+			  "value": null,
+			  "sensitive": true,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  },
+		  {
+			"id": "var-2",
+			"type": "vars",
+			"attributes": {
+			  "key": "region",
+			  "value": "eu-west-1",
+			  "sensitive": false,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  }
+		]
+	  }`
+
+	var requests []string
+
+	m := &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests = append(requests, req.Method+" "+req.URL.Path)
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+		}, nil
+	}}
+
+	c := NewClient(WithHTTPClient(m), WithLogger(log.New(ioutil.Discard, "", 0)))
+
+	v, err := c.GetVars(context.Background(), "ws-test")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	data, err := v.ToTfVars(true)
+
+	if err != nil || !strings.Contains(data, "# db_password is sensitive\n") {
+		t.Log(fmt.Printf("error expected db_password as a comment actual %s %v", data, err))
+		t.FailNow()
+	}
+
+	fileName := filepath.Join(t.TempDir(), "terraform.tfvars")
+	ioutil.WriteFile(fileName, []byte(data), 0600)
+
+	f := TerraformVars{}
+
+	if err := f.Load(fileName); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	r, err := c.UpsertVars(context.Background(), "ws-test", &f)
+
+	if err != nil || r.Count(StatusCreated) != 0 || r.Count(StatusUpdated) != 0 {
+		t.Log(fmt.Printf("error expected no variable created or updated actual %v %v", r.Keys, err))
+		t.Fail()
+	}
+
+	if len(requests) != 2 {
+		t.Log(fmt.Printf("error expected only the GET requests actual %v", requests))
+		t.Fail()
+	}
+}
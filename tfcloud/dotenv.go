@@ -0,0 +1,180 @@
+package tfcloud
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	errSingleQuote = fmt.Errorf("unterminated single quote")
+	errDoubleQuote = fmt.Errorf("unterminated double quote")
+
+	dotEnvKey      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dotEnvSafeChar = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]+$`)
+)
+
+// shellQuote quotes s for a POSIX shell, using single quotes when needed.
+// New lines are kept as they are: the quoted value spans many lines.
+func shellQuote(s string) string {
+
+	if dotEnvSafeChar.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ToDotEnv renders the env category variables as KEY=value lines.
+// Sensitive variables are written as comments because their value is not readable.
+func (v *TerraformVars) ToDotEnv() (data string, err error) {
+
+	vars := v.Filter(func(d Data) bool {
+		return d.Category == "env"
+	})
+
+	sort.SliceStable(vars.Data, func(i, j int) bool {
+		return vars.Data[i].Key < vars.Data[j].Key
+	})
+
+	var b strings.Builder
+
+	for _, d := range vars.Data {
+		if !dotEnvKey.MatchString(d.Key) {
+			return "", fmt.Errorf("%s is not a valid environment variable name", d.Key)
+		}
+
+		if d.Sensitive {
+			fmt.Fprintf(&b, "# %s is sensitive\n", d.Key)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", d.Key, shellQuote(d.Value))
+	}
+
+	return b.String(), nil
+}
+
+// IsDotEnv reports whether the file is a .env file.
+func IsDotEnv(fileName string) bool {
+	return strings.HasSuffix(fileName, ".env")
+}
+
+// unquote returns the value of a dotenv line with the shell quoting removed.
+// An unquoted # starts a comment.
+func unquote(s string) (string, error) {
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", errSingleQuote
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				} else if s[i] == '\\' && i+1 < len(s) && s[i+1] == 'n' {
+					b.WriteByte('\n')
+					i++
+					continue
+				}
+				b.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return "", errDoubleQuote
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == ' ' || c == '\t':
+			if rest := strings.TrimSpace(s[i:]); rest != "" && rest[0] != '#' {
+				return "", fmt.Errorf("unquoted space in value")
+			}
+			return b.String(), nil
+		case c == '#' && i == 0:
+			return "", nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// parseDotEnv reads the KEY=value lines of a .env file as env category variables.
+// A quoted value continues on the next lines until the closing quote.
+func parseDotEnv(src []byte) (data []Data, err error) {
+
+	s := bufio.NewScanner(bytes.NewReader(src))
+
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimLeft(s.Text(), " \t")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		i := strings.Index(line, "=")
+
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing =", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+
+		if !dotEnvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: %s is not a valid environment variable name", n, key)
+		}
+
+		start := n
+		raw := line[i+1:]
+		value, err := unquote(raw)
+
+		for ; (err == errSingleQuote || err == errDoubleQuote) && s.Scan(); n++ {
+			raw += "\n" + s.Text()
+			value, err = unquote(raw)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", start, err)
+		}
+
+		d := Data{Type: "vars"}
+		d.Key = key
+		d.Value = value
+		d.Category = "env"
+
+		data = append(data, d)
+	}
+
+	return data, s.Err()
+}
+
+// LoadDotEnv loads the variables of a .env file in the env category.
+func (v *TerraformVars) LoadDotEnv(fileName string) (err error) {
+
+	src, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	v.Data, err = parseDotEnv(src)
+
+	return err
+}
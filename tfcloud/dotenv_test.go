@@ -0,0 +1,107 @@
+package tfcloud
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToDotEnv(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_REGION", Value: "eu-south-1"}},
+		Data{Attributes: Attributes{Category: "env", Key: "ARM_FEATURES", Value: `{"a": "it's"}`}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_SECRET_ACCESS_KEY", Sensitive: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+	)
+
+	expected := `ARM_FEATURES='{"a": "it'\''s"}'
+AWS_REGION=eu-south-1
+# AWS_SECRET_ACCESS_KEY is sensitive
+`
+	actual, err := v.ToDotEnv()
+
+	if err != nil || expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s (%v)", expected, actual, err))
+		t.Fail()
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+
+	v := TerraformVars{}
+
+	err := v.Load("./mocks/aws.env")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := []Attributes{
+		{Category: "env", Key: "AWS_REGION", Value: "eu-south-1"},
+		{Category: "env", Key: "AWS_PROFILE", Value: "dev account"},
+		{Category: "env", Key: "ARM_FEATURES", Value: `{"a": "it's"}`},
+		{Category: "env", Key: "EMPTY", Value: ""},
+	}
+
+	if len(expected) != len(v.Data) {
+		t.Log(fmt.Printf("error expected %d actual %d", len(expected), len(v.Data)))
+		t.FailNow()
+	}
+
+	for i, a := range expected {
+		if a != v.Data[i].Attributes {
+			t.Log(fmt.Printf("error expected %v actual %v", a, v.Data[i].Attributes))
+			t.Fail()
+		}
+	}
+}
+
+func TestDotEnvInvalidLine(t *testing.T) {
+
+	for _, src := range []string{"AWS_REGION", "1KEY=value", "KEY='value", "KEY=a b"} {
+		if _, err := parseDotEnv([]byte(src)); err == nil {
+			t.Log(fmt.Printf("error expected %s to fail", src))
+			t.Fail()
+		}
+	}
+}
+
+func TestDotEnvRoundTrip(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "env", Key: "CERT", Value: "-----BEGIN-----\nMIIB\n-----END-----\n"}},
+		Data{Attributes: Attributes{Category: "env", Key: "SCRIPT", Value: "echo \"$HOME\" `id` \\n\nit's done"}},
+		Data{Attributes: Attributes{Category: "env", Key: "TEXT", Value: `it's a \n "test"`}},
+	)
+
+	s, err := v.ToDotEnv()
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// the new lines are kept in single quotes, as a POSIX shell reads them.
+	if !strings.Contains(s, "CERT='-----BEGIN-----\nMIIB\n-----END-----\n'\n") {
+		t.Log(fmt.Printf("error expected CERT single quoted on many lines actual %s", s))
+		t.Fail()
+	}
+
+	data, err := parseDotEnv([]byte(s))
+
+	if err != nil || len(data) != len(v.Data) {
+		t.Log(fmt.Printf("error expected %d variables actual %d (%v)", len(v.Data), len(data), err))
+		t.FailNow()
+	}
+
+	for i, d := range v.Data {
+		if d.Value != data[i].Value {
+			t.Log(fmt.Printf("error expected %q actual %q", d.Value, data[i].Value))
+			t.Fail()
+		}
+	}
+}
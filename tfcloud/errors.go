@@ -0,0 +1,145 @@
+package tfcloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// ErrorObject is an entry of the JSON:API errors array.
+type ErrorObject struct {
+	Status string      `json:"status"`
+	Title  string      `json:"title"`
+	Detail string      `json:"detail,omitempty"`
+	Source ErrorSource `json:"source"`
+}
+
+// APIError is returned when the api answers with an unexpected status code.
+// Errors is the decoded JSON:API errors array of the response, if any.
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorObject `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+
+	msg := fmt.Sprintf("Http request status code %d", e.StatusCode)
+
+	var details []string
+
+	for _, o := range e.Errors {
+		d := o.Title
+
+		if o.Detail != "" {
+			d = fmt.Sprintf("%s: %s", d, o.Detail)
+		}
+
+		if o.Source.Pointer != "" {
+			d = fmt.Sprintf("%s (%s)", d, o.Source.Pointer)
+		}
+
+		details = append(details, d)
+	}
+
+	if len(details) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(details, "; "))
+	}
+
+	return msg
+}
+
+// newAPIError decodes the errors of the response body. Bodies that are not
+// a JSON:API errors document only set the status code.
+func newAPIError(status int, body []byte) *APIError {
+
+	e := &APIError{}
+	json.Unmarshal(body, e)
+	e.StatusCode = status
+
+	return e
+}
+
+// notFoundError is a readable message for the APIError of a missing resource.
+type notFoundError struct {
+	msg string
+	err *APIError
+}
+
+func (e *notFoundError) Error() string {
+	return e.msg
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// checkResponse returns an APIError when the status code of the response
+// is not the expected one.
+func checkResponse(resp *http.Response, expected int) error {
+
+	if resp.StatusCode == expected {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Body)
+
+	return newAPIError(resp.StatusCode, buf.Bytes())
+}
+
+// statusCode returns the status code of the APIError wrapped by err, or 0.
+func statusCode(err error) int {
+
+	var e *APIError
+
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+// IsNotFound reports whether the resource or one of its parents does not exist.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether the token is missing, invalid or expired.
+func IsUnauthorized(err error) bool {
+	return statusCode(err) == http.StatusUnauthorized
+}
+
+// IsConflict reports whether the resource already exists, eg: a variable
+// with the same key and category is already defined.
+func IsConflict(err error) bool {
+
+	var e *APIError
+
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	if e.StatusCode == http.StatusConflict {
+		return true
+	}
+
+	if e.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+
+	for _, o := range e.Errors {
+		if strings.Contains(o.Detail, "has already been taken") {
+			return true
+		}
+	}
+
+	return false
+}
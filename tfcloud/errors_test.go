@@ -0,0 +1,110 @@
+package tfcloud
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+func TestAPIErrorKeyAlreadyTaken(t *testing.T) {
+
+	j := `{
+		"errors": [
+		  {
+			"status": "422",
+			"title": "invalid attribute",
+			"detail": "Key has already been taken",
+			"source": {
+			  "pointer": "/data/attributes/key"
+			}
+		  }
+		]
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
+
+		return &http.Response{
+			StatusCode: 422,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+		}, nil
+	}
+
+	p := newPayload("ws-test", Attributes{Category: "terraform", Key: "key", Value: "value"})
+
+	err := p.Post("ws-test", "")
+
+	if !IsConflict(err) || IsNotFound(err) || IsUnauthorized(err) {
+		t.Log(fmt.Printf("error expected a conflict actual %v", err))
+		t.Fail()
+	}
+
+	e, ok := err.(*APIError)
+
+	if !ok || e.Errors[0].Source.Pointer != "/data/attributes/key" {
+		t.Log(fmt.Printf("error expected an APIError actual %v", err))
+		t.Fail()
+	}
+
+	expected := "Http request status code 422: invalid attribute: Key has already been taken (/data/attributes/key)"
+
+	if expected != err.Error() {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, err.Error()))
+		t.Fail()
+	}
+}
+
+func TestAPIErrorInvalidCategory(t *testing.T) {
+
+	j := `{
+		"errors": [
+		  {
+			"status": "422",
+			"title": "invalid attribute",
+			"detail": "Category is not included in the list",
+			"source": {
+			  "pointer": "/data/attributes/category"
+			}
+		  }
+		]
+	  }`
+
+	err := newAPIError(422, []byte(j))
+
+	if IsConflict(err) {
+		t.Log("error expected an invalid category not to be a conflict")
+		t.Fail()
+	}
+}
+
+func TestAPIErrorUnauthorized(t *testing.T) {
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
+
+		return &http.Response{
+			StatusCode: 401,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("unauthorized"))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+
+	err := v.Get("ws-test", "")
+
+	if !IsUnauthorized(err) {
+		t.Log(fmt.Printf("error expected unauthorized actual %v", err))
+		t.Fail()
+	}
+
+	if !IsUnauthorized(fmt.Errorf("wrapped: %w", err)) {
+		t.Log("error expected a wrapped error to be unauthorized")
+		t.Fail()
+	}
+}
@@ -0,0 +1,152 @@
+package tfcloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// hclTokens returns the tokens of the hcl expression s.
+func hclTokens(s string) (hclwrite.Tokens, error) {
+
+	if _, diags := hclsyntax.ParseExpression([]byte(s), "", hcl.InitialPos); diags.HasErrors() {
+		return nil, diags
+	}
+
+	// the expression is parsed as the value of an attribute to get its tokens.
+	f, diags := hclwrite.ParseConfig([]byte("v = "+s+"\n"), "", hcl.InitialPos)
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return f.Body().GetAttribute("v").Expr().BuildTokens(nil), nil
+}
+
+// heredocTokens returns s as an heredoc string. s must end with a new line,
+// the heredoc syntax always adds it.
+func heredocTokens(s string) (hclwrite.Tokens, error) {
+
+	delimiter := "EOT"
+
+	for n := 1; strings.Contains("\n"+s, "\n"+delimiter+"\n"); n++ {
+		delimiter = fmt.Sprintf("EOT%d", n)
+	}
+
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+
+	return hclTokens("<<" + delimiter + "\n" + s + delimiter + "\n")
+}
+
+// setTfVar writes the attribute a in the body b.
+func setTfVar(b *hclwrite.Body, a Attributes) error {
+
+	if !hclsyntax.ValidIdentifier(a.Key) {
+		return fmt.Errorf("%s is not a valid variable name", a.Key)
+	}
+
+	switch {
+	case a.Sensitive:
+		// the value is not readable: a placeholder would be used as the secret.
+		b.AppendUnstructuredTokens(hclwrite.Tokens{{
+			Type:  hclsyntax.TokenComment,
+			Bytes: []byte(fmt.Sprintf("# %s is sensitive\n", a.Key)),
+		}})
+	case a.Hcl:
+		t, err := hclTokens(a.Value)
+
+		if err != nil {
+			return fmt.Errorf("%s invalid hcl value: %s", a.Key, err)
+		}
+
+		b.SetAttributeRaw(a.Key, t)
+	case strings.Contains(strings.TrimSuffix(a.Value, "\n"), "\n") && strings.HasSuffix(a.Value, "\n"):
+		t, err := heredocTokens(a.Value)
+
+		if err != nil {
+			return fmt.Errorf("%s invalid string value: %s", a.Key, err)
+		}
+
+		b.SetAttributeRaw(a.Key, t)
+	default:
+		b.SetAttributeValue(a.Key, cty.StringVal(a.Value))
+	}
+
+	return nil
+}
+
+// hclValue serialises the value v as a workspace variable value.
+// Strings are kept as they are, the other primitive types are written as
+// literals and the complex values as hcl expressions.
+func hclValue(v cty.Value) (value string, isHcl bool) {
+
+	switch {
+	case v.IsNull():
+		return "null", true
+	case v.Type() == cty.String:
+		return v.AsString(), false
+	case v.Type().IsPrimitiveType():
+		return string(hclwrite.TokensForValue(v).Bytes()), false
+	}
+
+	return strings.TrimSpace(string(hclwrite.Format(hclwrite.TokensForValue(v).Bytes()))), true
+}
+
+// parseTfVars reads the variables defined in the tfvars file src.
+// The file is parsed with the json syntax when json is true.
+func parseTfVars(src []byte, fileName string, json bool, category string) (data []Data, err error) {
+
+	var f *hcl.File
+	var diags hcl.Diagnostics
+
+	if json {
+		f, diags = hcljson.Parse(src, fileName)
+	} else {
+		f, diags = hclsyntax.ParseConfig(src, fileName, hcl.InitialPos)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, diags := f.Body.JustAttributes()
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	keys := make([]*hcl.Attribute, 0, len(attrs))
+
+	for _, a := range attrs {
+		keys = append(keys, a)
+	}
+
+	// keep the order of the file.
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Range.Start.Byte < keys[j].Range.Start.Byte
+	})
+
+	for _, a := range keys {
+		v, diags := a.Expr.Value(nil)
+
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		d := Data{Type: "vars"}
+		d.Key = a.Name
+		d.Category = category
+		d.Value, d.Hcl = hclValue(v)
+
+		data = append(data, d)
+	}
+
+	return data, nil
+}
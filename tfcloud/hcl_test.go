@@ -0,0 +1,74 @@
+package tfcloud
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToTfVarsValues(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "tags", Hcl: true, Value: "{\n  env = \"prod\"\n  \"cost-center\" = \"42\"\n}"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "policy", Value: "{\n  \"Version\": \"${v}\"\n}\n"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "braces", Value: "a {b] \"c\""}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "password", Value: "", Sensitive: true}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_REGION", Value: "eu-south-1"}},
+	)
+
+	expected := `braces = "a {b] \"c\""
+# password is sensitive
+policy = <<EOT
+{
+  "Version": "$${v}"
+}
+EOT
+tags = {
+  env           = "prod"
+  "cost-center" = "42"
+}
+`
+	actual, err := v.ToTfVars(true)
+
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
+
+func TestToTfVarsInvalidHcl(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "list", Hcl: true, Value: "[\"a\""}},
+	)
+
+	if _, err := v.ToTfVars(false); err == nil {
+		t.Log("error expected an invalid hcl value to fail")
+		t.Fail()
+	}
+}
+
+func TestHeredocDelimiter(t *testing.T) {
+
+	tokens, err := heredocTokens("a\nEOT\n")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := "<<EOT1\na\nEOT\nEOT1"
+	actual := strings.TrimSpace(string(tokens.Bytes()))
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
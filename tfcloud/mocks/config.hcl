@@ -0,0 +1,14 @@
+default_profile = "prod"
+
+profile "prod" {
+  host         = "app.terraform.io"
+  organization = "pagopa"
+  format       = "tfvars"
+  token_env    = "TFCLOUDVARS_TEST_TOKEN"
+}
+
+profile "tfe" {
+  host         = "tfe.example.com"
+  organization = "internal"
+  token_file   = "token"
+}
@@ -0,0 +1,11 @@
+terraform {
+  required_version = ">= 0.14"
+}
+
+locals {
+  prefix = "${var.name}-${var.region}"
+}
+
+output "prefix" {
+  value = local.prefix
+}
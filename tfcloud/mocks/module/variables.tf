@@ -0,0 +1,46 @@
+variable "name" {
+  type        = string
+  description = "name of the application"
+
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]+$", var.name))
+    error_message = "The name must be lowercase and start with a letter."
+  }
+}
+
+variable "zones" {
+  type = list(string)
+
+  validation {
+    condition     = length(var.zones) > 0
+    error_message = "At least a zone is required."
+  }
+}
+
+variable "instances" {
+  type    = number
+  default = 1
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "region" {
+  type    = "string"
+  default = null
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = optional(list(string), [])
+  })
+  default = null
+}
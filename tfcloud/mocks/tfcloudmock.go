@@ -12,7 +12,10 @@ var (
 	GetDoFunc func(req *http.Request) (*http.Response, error)
 )
 
-// Do is the mock client's `Do` func
+// Do is the mock client's `Do` func. It calls DoFunc when set, GetDoFunc otherwise.
 func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
+	if m.DoFunc != nil {
+		return m.DoFunc(req)
+	}
 	return GetDoFunc(req)
 }
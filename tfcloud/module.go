@@ -0,0 +1,445 @@
+package tfcloud
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// VarValidation is a validation block of a module variable.
+type VarValidation struct {
+	Condition    hcl.Expression
+	ErrorMessage string
+}
+
+// ModuleVar is a variable declared by a terraform module.
+type ModuleVar struct {
+	Name string
+	// Type is the type constraint, cty.DynamicPseudoType when any type is accepted.
+	Type cty.Type
+	// Default is the default value, if HasDefault.
+	Default     cty.Value
+	HasDefault  bool
+	Sensitive   bool
+	Validations []VarValidation
+}
+
+// Required reports whether the variable must be set: it has no default value.
+func (m *ModuleVar) Required() bool {
+	return !m.HasDefault
+}
+
+// Module is the set of variables declared by the .tf and .tf.json files of a directory.
+type Module struct {
+	Dir  string
+	Vars map[string]ModuleVar
+}
+
+var (
+	moduleSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+		},
+	}
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "type"},
+			{Name: "default"},
+			{Name: "sensitive"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "validation"},
+		},
+	}
+	validationSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "condition", Required: true},
+			{Name: "error_message"},
+		},
+	}
+)
+
+// LoadModule reads the variable blocks of the module in the directory dir.
+func LoadModule(dir string) (m Module, err error) {
+
+	m = Module{Dir: dir, Vars: map[string]ModuleVar{}}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+
+	if err != nil {
+		return m, err
+	}
+
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	files = append(files, jsonFiles...)
+
+	if len(files) == 0 {
+		return m, fmt.Errorf("no terraform files in %s", dir)
+	}
+
+	p := hclparse.NewParser()
+
+	for _, fileName := range files {
+
+		var f *hcl.File
+		var diags hcl.Diagnostics
+
+		if strings.HasSuffix(fileName, ".json") {
+			f, diags = p.ParseJSONFile(fileName)
+		} else {
+			f, diags = p.ParseHCLFile(fileName)
+		}
+
+		if diags.HasErrors() {
+			return m, diags
+		}
+
+		content, _, diags := f.Body.PartialContent(moduleSchema)
+
+		if diags.HasErrors() {
+			return m, diags
+		}
+
+		for _, b := range content.Blocks {
+			v, err := decodeVariable(b)
+
+			if err != nil {
+				return m, err
+			}
+
+			if _, found := m.Vars[v.Name]; found {
+				return m, fmt.Errorf("%s: variable %q declared more than once", b.DefRange, v.Name)
+			}
+
+			m.Vars[v.Name] = v
+		}
+	}
+
+	return m, nil
+}
+
+// decodeVariable decodes a variable block.
+func decodeVariable(b *hcl.Block) (v ModuleVar, err error) {
+
+	v = ModuleVar{Name: b.Labels[0], Type: cty.DynamicPseudoType}
+
+	content, _, diags := b.Body.PartialContent(variableSchema)
+
+	if diags.HasErrors() {
+		return v, diags
+	}
+
+	if a, ok := content.Attributes["type"]; ok {
+		if v.Type, err = typeConstraint(a.Expr); err != nil {
+			return v, err
+		}
+	}
+
+	if a, ok := content.Attributes["sensitive"]; ok {
+		if diags := constValue(a.Expr, &v.Sensitive); diags.HasErrors() {
+			return v, diags
+		}
+	}
+
+	if a, ok := content.Attributes["default"]; ok {
+		val, diags := a.Expr.Value(nil)
+
+		if diags.HasErrors() {
+			return v, diags
+		}
+
+		if !val.IsNull() {
+			if val, err = convert.Convert(val, v.Type); err != nil {
+				return v, fmt.Errorf("%s: invalid default value of %s: %s", a.Range, v.Name, err)
+			}
+		}
+
+		v.Default, v.HasDefault = val, true
+	}
+
+	for _, vb := range content.Blocks {
+		c, diags := vb.Body.Content(validationSchema)
+
+		if diags.HasErrors() {
+			return v, diags
+		}
+
+		vv := VarValidation{Condition: c.Attributes["condition"].Expr}
+
+		if a, ok := c.Attributes["error_message"]; ok {
+			constValue(a.Expr, &vv.ErrorMessage)
+		}
+
+		v.Validations = append(v.Validations, vv)
+	}
+
+	return v, nil
+}
+
+// typeConstraint returns the type of the type constraint expression, eg:
+// list(string). The legacy quoted types "string", "list" and "map" are accepted too.
+func typeConstraint(expr hcl.Expression) (cty.Type, error) {
+
+	var legacy string
+
+	if diags := constValue(expr, &legacy); !diags.HasErrors() {
+		switch legacy {
+		case "string":
+			return cty.String, nil
+		case "list":
+			return cty.List(cty.DynamicPseudoType), nil
+		case "map":
+			return cty.Map(cty.DynamicPseudoType), nil
+		}
+	}
+
+	t, diags := typeexpr.TypeConstraint(expr)
+
+	if diags.HasErrors() {
+		// the optional object attributes of terraform 1.3 are not supported
+		// here: any value is accepted and the type is left to terraform.
+		if usesOptional(expr) {
+			return cty.DynamicPseudoType, nil
+		}
+		return t, diags
+	}
+
+	return t, nil
+}
+
+// usesOptional reports whether the type constraint expression declares
+// optional object attributes, eg: object({a = optional(string)})
+func usesOptional(expr hcl.Expression) bool {
+
+	node, ok := expr.(hclsyntax.Node)
+
+	if !ok {
+		return false
+	}
+
+	found := false
+
+	hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		if call, ok := n.(*hclsyntax.FunctionCallExpr); ok && call.Name == "optional" {
+			found = true
+		}
+		return nil
+	})
+
+	return found
+}
+
+// constValue evaluates the constant expression into the string or bool pointed by dst.
+func constValue(expr hcl.Expression, dst interface{}) hcl.Diagnostics {
+
+	val, diags := expr.Value(nil)
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	var err error
+
+	switch d := dst.(type) {
+	case *string:
+		if val, err = convert.Convert(val, cty.String); err == nil && !val.IsNull() {
+			*d = val.AsString()
+		}
+	case *bool:
+		if val, err = convert.Convert(val, cty.Bool); err == nil && !val.IsNull() {
+			*d = val.True()
+		}
+	}
+
+	if err != nil {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid value",
+			Detail:   err.Error(),
+			Subject:  expr.Range().Ptr(),
+		}}
+	}
+
+	return nil
+}
+
+// Violation is a variable that does not match the module declarations.
+type Violation struct {
+	Key    string
+	Reason string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Key, v.Reason)
+}
+
+// Violations are the variables that do not match the module declarations.
+type Violations []Violation
+
+func (e Violations) Error() string {
+
+	msgs := make([]string, len(e))
+
+	for i, v := range e {
+		msgs[i] = v.String()
+	}
+
+	return fmt.Sprintf("%d variables not valid: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Validate checks the terraform category variables of v against the module
+// declarations and reports:
+// the required variables missing in v, the variables not declared,
+// the values that do not match the type constraint or fail a validation
+// condition and the values of variables declared sensitive that are not sensitive.
+// Empty sensitive values, as returned by the api, are not checked.
+// Validation conditions that cannot be evaluated, eg: using a function not
+// available here, and the types with optional attributes are left to terraform.
+// The violations are sorted by key.
+func (m *Module) Validate(v *TerraformVars) (e Violations) {
+
+	vars := v.Filter(func(d Data) bool {
+		return d.Category != "env"
+	})
+
+	for _, d := range vars.Data {
+		decl, found := m.Vars[d.Key]
+
+		if !found {
+			e = append(e, Violation{d.Key, "not declared by the module"})
+			continue
+		}
+
+		if decl.Sensitive && !d.Sensitive {
+			e = append(e, Violation{d.Key, "declared sensitive, the value must be sensitive too"})
+		}
+
+		if d.Sensitive && d.Value == "" {
+			continue
+		}
+
+		if reason := decl.check(d.Attributes); reason != "" {
+			e = append(e, Violation{d.Key, reason})
+		}
+	}
+
+	for name, decl := range m.Vars {
+		if _, found := vars.Find(name, "terraform"); decl.Required() && !found {
+			e = append(e, Violation{name, "required by the module, not defined"})
+		}
+	}
+
+	sort.SliceStable(e, func(i, j int) bool {
+		return e[i].Key < e[j].Key
+	})
+
+	return e
+}
+
+// check returns why the value of the variable a does not match the
+// declaration, or an empty string if it matches.
+func (m *ModuleVar) check(a Attributes) string {
+
+	val := cty.StringVal(a.Value)
+
+	if a.Hcl {
+		expr, diags := hclsyntax.ParseExpression([]byte(a.Value), a.Key, hcl.InitialPos)
+
+		if diags.HasErrors() {
+			return fmt.Sprintf("invalid hcl value: %s", diags.Error())
+		}
+
+		if val, diags = expr.Value(nil); diags.HasErrors() {
+			return fmt.Sprintf("invalid hcl value: %s", diags.Error())
+		}
+	}
+
+	val, err := convert.Convert(val, m.Type)
+
+	if err != nil {
+		return fmt.Sprintf("wrong type: %s", err)
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{m.Name: val}),
+		},
+		Functions: functions,
+	}
+
+	for _, vv := range m.Validations {
+		ok, diags := vv.Condition.Value(ctx)
+
+		// conditions that cannot be evaluated here are left to terraform.
+		if diags.HasErrors() || ok.IsNull() || !ok.IsKnown() || ok.Type() != cty.Bool {
+			continue
+		}
+
+		if ok.False() {
+			if vv.ErrorMessage == "" {
+				return "validation condition failed"
+			}
+			return vv.ErrorMessage
+		}
+	}
+
+	return ""
+}
+
+// functions are the terraform functions available to the validation conditions.
+var functions = map[string]function.Function{
+	"abs":       stdlib.AbsoluteFunc,
+	"can":       tryfunc.CanFunc,
+	"ceil":      stdlib.CeilFunc,
+	"coalesce":  stdlib.CoalesceFunc,
+	"compact":   stdlib.CompactFunc,
+	"concat":    stdlib.ConcatFunc,
+	"contains":  stdlib.ContainsFunc,
+	"distinct":  stdlib.DistinctFunc,
+	"floor":     stdlib.FloorFunc,
+	"format":    stdlib.FormatFunc,
+	"join":      stdlib.JoinFunc,
+	"keys":      stdlib.KeysFunc,
+	"length":    lengthFunc,
+	"lower":     stdlib.LowerFunc,
+	"max":       stdlib.MaxFunc,
+	"min":       stdlib.MinFunc,
+	"regex":     stdlib.RegexFunc,
+	"regexall":  stdlib.RegexAllFunc,
+	"split":     stdlib.SplitFunc,
+	"substr":    stdlib.SubstrFunc,
+	"tonumber":  stdlib.MakeToFunc(cty.Number),
+	"tostring":  stdlib.MakeToFunc(cty.String),
+	"trimspace": stdlib.TrimSpaceFunc,
+	"try":       tryfunc.TryFunc,
+	"upper":     stdlib.UpperFunc,
+	"values":    stdlib.ValuesFunc,
+}
+
+// lengthFunc is the terraform length function: the number of characters
+// of a string or of elements of a collection.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+
+		return stdlib.Length(args[0])
+	},
+})
@@ -0,0 +1,126 @@
+package tfcloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadModule(t *testing.T) {
+
+	m, err := LoadModule("./mocks/module")
+
+	if err != nil {
+		t.Log(fmt.Printf("error loading the module %v", err))
+		t.FailNow()
+	}
+
+	if len(m.Vars) != 7 {
+		t.Log(fmt.Printf("error expected 7 variables actual %d", len(m.Vars)))
+		t.Fail()
+	}
+
+	// the optional attributes are not supported: any type is accepted.
+	if v := m.Vars["network"]; !v.Type.Equals(cty.DynamicPseudoType) || v.Required() {
+		t.Log(fmt.Printf("error expected network of any type actual %#v", v))
+		t.Fail()
+	}
+
+	zones := m.Vars["zones"]
+
+	if !zones.Type.Equals(cty.List(cty.String)) || !zones.Required() || len(zones.Validations) != 1 {
+		t.Log(fmt.Printf("error unexpected zones declaration %#v", zones))
+		t.Fail()
+	}
+
+	if v := m.Vars["instances"]; v.Required() || !v.Default.RawEquals(cty.NumberIntVal(1)) {
+		t.Log(fmt.Printf("error unexpected instances declaration %#v", v))
+		t.Fail()
+	}
+
+	if v := m.Vars["region"]; v.Required() || !v.Type.Equals(cty.String) {
+		t.Log(fmt.Printf("error expected region optional with the legacy string type %#v", v))
+		t.Fail()
+	}
+
+	if !m.Vars["db_password"].Sensitive {
+		t.Log("error expected db_password sensitive")
+		t.Fail()
+	}
+
+	if _, err := LoadModule("./mocks/notfound"); err == nil {
+		t.Log("error expected a directory without terraform files to fail")
+		t.Fail()
+	}
+}
+
+func TestModuleValidate(t *testing.T) {
+
+	m, err := LoadModule("./mocks/module")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "zones", Value: `["a", "b"]`, Hcl: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "instances", Value: "3"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "db_password", Sensitive: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "network", Value: `{ cidr = "10.0.0.0/16" }`, Hcl: true}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_REGION", Value: "eu-south-1"}},
+	)
+
+	if e := m.Validate(&v); len(e) != 0 {
+		t.Log(fmt.Printf("error expected no violations actual %v", e))
+		t.Fail()
+	}
+
+	v = TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "Api"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "zones", Value: "a"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "instances", Value: "three"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "tags", Value: `{ env = "prod" }`, Hcl: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "db_password", Value: "secret"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "legacy", Value: "x"}},
+	)
+
+	e := m.Validate(&v)
+
+	expected := []string{"db_password", "instances", "legacy", "name", "zones"}
+
+	if len(e) != len(expected) {
+		t.Log(fmt.Printf("error expected %d violations actual %v", len(expected), e))
+		t.FailNow()
+	}
+
+	for i, key := range expected {
+		if e[i].Key != key {
+			t.Log(fmt.Printf("error expected %s actual %s", key, e[i]))
+			t.Fail()
+		}
+	}
+
+	if e[3].Reason != "The name must be lowercase and start with a letter." {
+		t.Log(fmt.Printf("error expected the validation error message actual %s", e[3].Reason))
+		t.Fail()
+	}
+
+	// zones is a string: a list is required.
+	v = TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "zones", Value: "[]", Hcl: true}},
+	)
+
+	e = m.Validate(&v)
+
+	if len(e) != 2 || e[0].Key != "db_password" || e[1].Reason != "At least a zone is required." {
+		t.Log(fmt.Printf("error expected db_password missing and zones empty actual %v", e))
+		t.Fail()
+	}
+}
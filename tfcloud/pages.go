@@ -0,0 +1,89 @@
+package tfcloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Pagination is the JSON:API pagination metadata of a list response.
+type Pagination struct {
+	CurrentPage int `json:"current-page"`
+	NextPage    int `json:"next-page"`
+	TotalPages  int `json:"total-pages"`
+	TotalCount  int `json:"total-count"`
+}
+
+type pageLinks struct {
+	Next string `json:"next"`
+}
+
+type pageMeta struct {
+	Pagination Pagination `json:"pagination"`
+}
+
+type page struct {
+	Links pageLinks `json:"links"`
+	Meta  pageMeta  `json:"meta"`
+}
+
+// getPages fetches u and the following pages of a paginated list, calling
+// f with the body of each page until links.next is exhausted.
+func (c *Client) getPages(ctx context.Context, u string, f func(body []byte) error) (err error) {
+
+	for u != "" {
+		status, body, err := c.get(ctx, u)
+
+		if err != nil {
+			return err
+		}
+
+		if status != http.StatusOK {
+			return newAPIError(status, body)
+		}
+
+		if err := f(body); err != nil {
+			return err
+		}
+
+		p := page{}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			return err
+		}
+
+		if u, err = nextPage(u, p.Links.Next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextPage resolves the next link against the current page url.
+// It returns an empty string when there are no more pages.
+func nextPage(current string, next string) (string, error) {
+
+	if next == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+
+	r := base.ResolveReference(n).String()
+
+	if r == current {
+		return "", nil
+	}
+
+	return r, nil
+}
@@ -0,0 +1,86 @@
+package tfcloud
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+func TestGetPages(t *testing.T) {
+
+	pages := map[string]string{
+		"1": `{
+			"data": [{"id": "var-1", "type": "vars", "attributes": {"key": "one"}}],
+			"links": {"next": "https://app.terraform.io/api/v2/workspaces/ws-test/vars?page%5Bnumber%5D=2"},
+			"meta": {"pagination": {"current-page": 1, "next-page": 2, "total-pages": 2, "total-count": 2}}
+		}`,
+		"2": `{
+			"data": [{"id": "var-2", "type": "vars", "attributes": {"key": "two"}}],
+			"links": {"next": null},
+			"meta": {"pagination": {"current-page": 2, "total-pages": 2, "total-count": 2}}
+		}`,
+	}
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	requests := 0
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		requests++
+
+		n := req.URL.Query().Get("page[number]")
+		if n == "" {
+			n = "1"
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(pages[n]))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+
+	if err := v.Get("ws-test", ""); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := 2
+	actual := len(v.Data)
+
+	if expected != actual || requests != 2 {
+		t.Log(fmt.Printf("error expected %d actual %d in %d requests", expected, actual, requests))
+		t.FailNow()
+	}
+
+	if v.Data[1].Key != "two" {
+		t.Log(fmt.Printf("error expected two actual %s", v.Data[1].Key))
+		t.Fail()
+	}
+}
+
+func TestNextPage(t *testing.T) {
+
+	current := "https://app.terraform.io/api/v2/organizations/test/workspaces"
+
+	actual, _ := nextPage(current, "/api/v2/organizations/test/workspaces?page%5Bnumber%5D=2")
+	expected := "https://app.terraform.io/api/v2/organizations/test/workspaces?page%5Bnumber%5D=2"
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+
+	actual, _ = nextPage(current, "")
+
+	if actual != "" {
+		t.Log(fmt.Printf("error expected no next page actual %s", actual))
+		t.Fail()
+	}
+}
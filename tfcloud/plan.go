@@ -0,0 +1,126 @@
+package tfcloud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Action is the change a plan applies to a single variable.
+type Action string
+
+const (
+	ActionCreate Action = "+"
+	ActionUpdate Action = "~"
+	ActionDelete Action = "-"
+	// ActionUnknown is a sensitive variable whose value can not be
+	// compared: a load writes it again.
+	ActionUnknown Action = "?"
+)
+
+// Change describes how a variable differs between the workspace and the file.
+// Old is empty for created variables and New is empty for deleted ones.
+type Change struct {
+	Action Action
+	Old    Attributes
+	New    Attributes
+}
+
+// Plan is the list of changes needed to load a file into a workspace.
+type Plan struct {
+	Changes []Change
+}
+
+// Plan compares the variables of v with the ones currently defined in the
+// workspace. When prune is true the variables not defined in v are deleted,
+// only in the category if not empty, see Stale.
+func (v *TerraformVars) Plan(current *TerraformVars, prune bool, category string) (p Plan) {
+
+	for _, d := range v.Data {
+		e, found := current.Find(d.Key, d.Category)
+
+		switch {
+		case !found:
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, New: d.Attributes})
+		case unknownValue(d.Attributes):
+			// left unchanged by a load.
+		case e.Sensitive && d.Sensitive && e.Description == d.Description && e.Hcl == d.Hcl:
+			p.Changes = append(p.Changes, Change{Action: ActionUnknown, Old: e.Attributes, New: d.Attributes})
+		case !sameAttributes(e.Attributes, d.Attributes):
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Old: e.Attributes, New: d.Attributes})
+		}
+	}
+
+	if prune {
+		for _, d := range v.Stale(current, category) {
+			p.Changes = append(p.Changes, Change{Action: ActionDelete, Old: d.Attributes})
+		}
+	}
+
+	return p
+}
+
+// Count returns the number of changes with the action a.
+func (p *Plan) Count(a Action) int {
+
+	n := 0
+
+	for _, c := range p.Changes {
+		if c.Action == a {
+			n++
+		}
+	}
+
+	return n
+}
+
+// planValue formats the value of a variable masking the sensitive ones.
+func planValue(a Attributes) string {
+
+	if a.Sensitive {
+		return "(sensitive value)"
+	}
+
+	if a.Hcl {
+		return a.Value
+	}
+
+	return fmt.Sprintf("%q", a.Value)
+}
+
+// String renders the plan in the terraform plan style.
+func (p *Plan) String() string {
+
+	var b strings.Builder
+
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(&b, "  + %s.%s = %s\n", c.New.Category, c.New.Key, planValue(c.New))
+		case ActionUpdate:
+			fmt.Fprintf(&b, "  ~ %s.%s = %s -> %s\n", c.New.Category, c.New.Key, planValue(c.Old), planValue(c.New))
+		case ActionDelete:
+			fmt.Fprintf(&b, "  - %s.%s = %s\n", c.Old.Category, c.Old.Key, planValue(c.Old))
+		case ActionUnknown:
+			fmt.Fprintf(&b, "  ? %s.%s = %s\n", c.New.Category, c.New.Key, planValue(c.New))
+		}
+	}
+
+	unknown := p.Count(ActionUnknown)
+
+	if len(p.Changes) > 0 {
+		b.WriteString("\n")
+	}
+
+	if len(p.Changes) == unknown {
+		b.WriteString("No changes. The workspace variables match the file.\n")
+	} else {
+		fmt.Fprintf(&b, "Plan: %d to add, %d to change, %d to destroy.\n",
+			p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+	}
+
+	if unknown > 0 {
+		fmt.Fprintf(&b, "%d sensitive values can not be compared: a load writes them again.\n", unknown)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package tfcloud
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+
+	current := TerraformVars{}
+	current.Data = append(current.Data,
+		Data{ID: "var-name", Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{ID: "var-count", Attributes: Attributes{Category: "terraform", Key: "count", Value: "1"}},
+		Data{ID: "var-old", Attributes: Attributes{Category: "terraform", Key: "old", Value: "x"}},
+	)
+
+	file := TerraformVars{}
+	file.Data = append(file.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "count", Value: "2"}},
+		Data{Attributes: Attributes{Category: "env", Key: "TOKEN", Value: "secret", Sensitive: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "cidr", Value: "[\"10.0.5.0/24\"]", Hcl: true}},
+	)
+
+	p := file.Plan(&current, true, "")
+
+	expected := `  ~ terraform.count = "1" -> "2"
+  + env.TOKEN = (sensitive value)
+  + terraform.cidr = ["10.0.5.0/24"]
+  - terraform.old = "x"
+
+Plan: 2 to add, 1 to change, 1 to destroy.
+`
+	actual := p.String()
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
+
+func TestPlanNoPrune(t *testing.T) {
+
+	current := TerraformVars{}
+	current.Data = append(current.Data,
+		Data{ID: "var-old", Attributes: Attributes{Category: "terraform", Key: "old", Value: "x"}},
+	)
+
+	file := TerraformVars{}
+
+	p := file.Plan(&current, false, "")
+
+	expected := "No changes. The workspace variables match the file.\n"
+	actual := p.String()
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
+
+func TestPlanSensitive(t *testing.T) {
+
+	current := TerraformVars{}
+	current.Data = append(current.Data,
+		Data{ID: "var-token", Attributes: Attributes{Category: "env", Key: "TOKEN", Sensitive: true}},
+		Data{ID: "var-password", Attributes: Attributes{Category: "terraform", Key: "password", Sensitive: true}},
+	)
+
+	// the file written by read: the sensitive values are empty.
+	file := TerraformVars{Data: current.Data}
+
+	p := file.Plan(&current, true, "")
+
+	expected := "No changes. The workspace variables match the file.\n"
+	actual := p.String()
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+
+	file = TerraformVars{}
+	file.Data = append(file.Data,
+		Data{Attributes: Attributes{Category: "env", Key: "TOKEN", Value: "secret", Sensitive: true}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "password", Sensitive: true}},
+	)
+
+	p = file.Plan(&current, true, "")
+
+	expected = `  ? env.TOKEN = (sensitive value)
+
+No changes. The workspace variables match the file.
+1 sensitive values can not be compared: a load writes them again.
+`
+	actual = p.String()
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
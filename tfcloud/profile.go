@@ -0,0 +1,108 @@
+package tfcloud
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// Profile is a named set of settings of the tool: the host, the
+// organization, the output format and the source of the token.
+type Profile struct {
+	Name         string `hcl:"name,label"`
+	Host         string `hcl:"host,optional"`
+	Organization string `hcl:"organization,optional"`
+	Format       string `hcl:"format,optional"`
+	// the token is read from the first source set: the token itself,
+	// the env variable token_env or the file token_file.
+	Token     string `hcl:"token,optional"`
+	TokenEnv  string `hcl:"token_env,optional"`
+	TokenFile string `hcl:"token_file,optional"`
+}
+
+// ToolConfig is the configuration file of the tool, eg:
+// ~/.config/tfcloudvars/config.hcl
+type ToolConfig struct {
+	DefaultProfile string    `hcl:"default_profile,optional"`
+	Profiles       []Profile `hcl:"profile,block"`
+}
+
+// Read decodes the hcl, or json if the file name ends with .json, configuration file.
+// The relative token_file paths are resolved against the directory of the file.
+func (c *ToolConfig) Read(fileName string) (err error) {
+
+	if err := hclsimple.DecodeFile(fileName, nil, c); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+
+	for i, p := range c.Profiles {
+		if seen[p.Name] {
+			return fmt.Errorf("%s: profile %q defined more than once", fileName, p.Name)
+		}
+		seen[p.Name] = true
+
+		// a relative token_file is in the directory of the configuration file,
+		// wherever the tool is run from.
+		if p.TokenFile != "" && !filepath.IsAbs(p.TokenFile) {
+			c.Profiles[i].TokenFile = filepath.Join(filepath.Dir(fileName), p.TokenFile)
+		}
+	}
+
+	return nil
+}
+
+// Profile returns the profile called name or, if name is empty, the
+// default profile. With no name and no default profile it returns an
+// empty profile.
+func (c *ToolConfig) Profile(name string) (p Profile, err error) {
+
+	if name == "" {
+		name = c.DefaultProfile
+	}
+
+	if name == "" {
+		return p, nil
+	}
+
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return p, fmt.Errorf("profile %q not found", name)
+}
+
+// ReadToken returns the token of the profile read from its source.
+// found is false when the profile has no token source.
+func (p *Profile) ReadToken() (token string, found bool, err error) {
+
+	switch {
+	case p.Token != "":
+		return p.Token, true, nil
+	case p.TokenEnv != "":
+		token, found = os.LookupEnv(p.TokenEnv)
+
+		if !found {
+			return "", false, fmt.Errorf("profile %q: env variable %s not defined", p.Name, p.TokenEnv)
+		}
+
+		return token, true, nil
+	case p.TokenFile != "":
+		b, err := ioutil.ReadFile(p.TokenFile)
+
+		if err != nil {
+			return "", false, fmt.Errorf("profile %q: %s", p.Name, err)
+		}
+
+		return strings.TrimSpace(string(b)), true, nil
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,93 @@
+package tfcloud
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestReadToolConfig(t *testing.T) {
+
+	c := ToolConfig{}
+
+	if err := c.Read("./mocks/config.hcl"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	p, err := c.Profile("")
+
+	if err != nil || p.Name != "prod" || p.Organization != "pagopa" || p.Format != "tfvars" {
+		t.Log(fmt.Printf("error expected the default profile prod actual %v %v", p, err))
+		t.Fail()
+	}
+
+	p, err = c.Profile("tfe")
+
+	if err != nil || p.Host != "tfe.example.com" || p.Organization != "internal" {
+		t.Log(fmt.Printf("error expected the profile tfe actual %v %v", p, err))
+		t.Fail()
+	}
+
+	// the token file is next to the configuration file.
+	if token, _, err := p.ReadToken(); err != nil || token != "this.is.a.file.test" {
+		t.Log(fmt.Printf("error expected the token of the file actual %s %v", token, err))
+		t.Fail()
+	}
+
+	expected := `profile "missing" not found`
+
+	if _, err := c.Profile("missing"); err == nil || err.Error() != expected {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, err))
+		t.Fail()
+	}
+}
+
+func TestToolConfigFileNotFound(t *testing.T) {
+
+	c := ToolConfig{}
+
+	if err := c.Read("./mocks/notfound.hcl"); err == nil {
+		t.Log("error expected a missing file to fail")
+		t.Fail()
+	}
+
+	// without a default profile an empty profile is returned.
+	if p, err := c.Profile(""); err != nil || p.Name != "" {
+		t.Log(fmt.Printf("error expected an empty profile actual %v %v", p, err))
+		t.Fail()
+	}
+}
+
+func TestProfileReadToken(t *testing.T) {
+
+	os.Setenv("TFCLOUDVARS_TEST_TOKEN", "this.is.an.env.test")
+	defer os.Unsetenv("TFCLOUDVARS_TEST_TOKEN")
+
+	cases := []struct {
+		profile  Profile
+		expected string
+		found    bool
+	}{
+		{Profile{Token: "this.is.a.test"}, "this.is.a.test", true},
+		{Profile{TokenEnv: "TFCLOUDVARS_TEST_TOKEN"}, "this.is.an.env.test", true},
+		{Profile{TokenFile: "./mocks/token"}, "this.is.a.file.test", true},
+		{Profile{}, "", false},
+	}
+
+	for _, c := range cases {
+		actual, found, err := c.profile.ReadToken()
+
+		if err != nil || found != c.found || actual != c.expected {
+			t.Log(fmt.Printf("error expected %s actual %s %v", c.expected, actual, err))
+			t.Fail()
+		}
+	}
+
+	p := Profile{Name: "ci", TokenEnv: "TFCLOUDVARS_MISSING_TOKEN"}
+
+	if _, _, err := p.ReadToken(); err == nil {
+		t.Log("error expected a missing env variable to fail")
+		t.Fail()
+	}
+}
@@ -0,0 +1,95 @@
+package tfcloud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the outcome of the operation applied to a single variable.
+type Status string
+
+const (
+	StatusCreated   Status = "created"
+	StatusUpdated   Status = "updated"
+	StatusUnchanged Status = "unchanged"
+	StatusDeleted   Status = "deleted"
+	StatusFailed    Status = "failed"
+)
+
+// KeyResult is the outcome of the operation for one variable.
+// ID is the id of the variable, when known.
+type KeyResult struct {
+	Key      string
+	Category string
+	ID       string
+	Status   Status
+	Err      error
+}
+
+// Result collects the outcome of an operation applied to many variables.
+type Result struct {
+	Keys []KeyResult
+}
+
+// Count returns the number of variables with the status s.
+func (r *Result) Count(s Status) int {
+
+	n := 0
+
+	for _, k := range r.Keys {
+		if k.Status == s {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Applied returns the keys of the variables changed by the operation:
+// created, updated or deleted.
+func (r *Result) Applied() []string {
+
+	keys := []string{}
+
+	for _, k := range r.Keys {
+		switch k.Status {
+		case StatusCreated, StatusUpdated, StatusDeleted:
+			keys = append(keys, k.Key)
+		}
+	}
+
+	return keys
+}
+
+// KeyErrors are the variables failed by an operation.
+type KeyErrors []KeyResult
+
+func (e KeyErrors) Error() string {
+
+	msgs := make([]string, len(e))
+
+	for i, k := range e {
+		msgs[i] = fmt.Sprintf("%s: %s", k.Key, k.Err)
+	}
+
+	return fmt.Sprintf("%d variables failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Err returns the errors of the failed variables as KeyErrors,
+// or nil if no variable failed.
+func (r *Result) Err() error {
+
+	var e KeyErrors
+
+	for _, k := range r.Keys {
+		if k.Status == StatusFailed {
+			e = append(e, k)
+		}
+	}
+
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
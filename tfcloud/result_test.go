@@ -0,0 +1,76 @@
+package tfcloud
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestResultCount(t *testing.T) {
+
+	r := Result{Keys: []KeyResult{
+		{Key: "a", Status: StatusCreated},
+		{Key: "b", Status: StatusCreated},
+		{Key: "c", Status: StatusFailed, Err: errors.New("failed")},
+	}}
+
+	expected := 2
+	actual := r.Count(StatusCreated)
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %d actual %d", expected, actual))
+		t.Fail()
+	}
+
+	expected = 0
+	actual = r.Count(StatusUnchanged)
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %d actual %d", expected, actual))
+		t.Fail()
+	}
+}
+
+func TestResultApplied(t *testing.T) {
+
+	r := Result{Keys: []KeyResult{
+		{Key: "a", Status: StatusCreated},
+		{Key: "b", Status: StatusUnchanged},
+		{Key: "c", Status: StatusUpdated},
+		{Key: "d", Status: StatusFailed, Err: errors.New("failed")},
+		{Key: "e", Status: StatusDeleted},
+	}}
+
+	expected := "[a c e]"
+	actual := fmt.Sprint(r.Applied())
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
+
+func TestResultErr(t *testing.T) {
+
+	r := Result{Keys: []KeyResult{
+		{Key: "a", Status: StatusCreated},
+	}}
+
+	if err := r.Err(); err != nil {
+		t.Log(fmt.Printf("error expected no error actual %s", err))
+		t.Fail()
+	}
+
+	r.Keys = append(r.Keys,
+		KeyResult{Key: "b", Status: StatusFailed, Err: errors.New("invalid category")},
+		KeyResult{Key: "c", Status: StatusFailed, Err: errors.New("key has already been taken")},
+	)
+
+	expected := "2 variables failed: b: invalid category; c: key has already been taken"
+	actual := r.Err().Error()
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+}
@@ -0,0 +1,226 @@
+package tfcloud
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_MAX_RETRIES  = 5
+	DEFAULT_MIN_BACKOFF  = 500 * time.Millisecond
+	DEFAULT_MAX_BACKOFF  = 10 * time.Second
+	DEFAULT_MAX_WAITTIME = time.Minute
+)
+
+// RetryPolicy bounds the retries of a request.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries of a request.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the wait between two attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxWait caps the total time spent waiting to retry a request.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy is the policy of the package level functions.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: DEFAULT_MAX_RETRIES,
+	MinBackoff: DEFAULT_MIN_BACKOFF,
+	MaxBackoff: DEFAULT_MAX_BACKOFF,
+	MaxWait:    DEFAULT_MAX_WAITTIME,
+}
+
+// RetryClient retries the requests rejected by the rate limiter (429) and,
+// for the idempotent methods only, the ones failed with a 5xx status code
+// or a network error. The wait honours the Retry-After and X-RateLimit-Reset
+// headers and grows exponentially otherwise. The wait ends early when the
+// context of the request is done.
+// When the rate limiter asks to wait, the new requests sent through the
+// same RetryClient wait too, so the concurrent workers slow down together.
+type RetryClient struct {
+	Client HTTPClient
+	RetryPolicy
+
+	sleep func(time.Duration)
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// NewRetryClient wraps c with the default retry policy.
+func NewRetryClient(c HTTPClient) *RetryClient {
+	return &RetryClient{
+		Client:      c,
+		RetryPolicy: DefaultRetryPolicy,
+	}
+}
+
+// idempotent reports whether the request can be sent again without side effects.
+func idempotent(req *http.Request) bool {
+
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
+
+// retryable reports whether the attempt can be retried. A 429 response
+// means the request was not applied, so it is retried whatever the method.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+
+	if err != nil {
+		return idempotent(req)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	return resp.StatusCode >= 500 && idempotent(req)
+}
+
+// retryAfter returns the wait requested by the rate limit headers, if any.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+
+	if resp == nil {
+		return 0, false
+	}
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil {
+			return time.Duration(n) * time.Second, true
+		}
+		if t, err := http.ParseTime(s); err == nil {
+			return time.Until(t), true
+		}
+	}
+
+	if s := resp.Header.Get("X-RateLimit-Reset"); s != "" {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return time.Duration(f * float64(time.Second)), true
+		}
+	}
+
+	return 0, false
+}
+
+// backoff returns the wait before the attempt n, starting from 0.
+func (c *RetryClient) backoff(n int, resp *http.Response) time.Duration {
+
+	if d, ok := retryAfter(resp); ok {
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+
+	d := c.MinBackoff << uint(n)
+
+	if d > c.MaxBackoff || d <= 0 {
+		return c.MaxBackoff
+	}
+
+	return d
+}
+
+// wait pauses for d or until ctx is done.
+func (c *RetryClient) wait(ctx context.Context, d time.Duration) error {
+
+	if c.sleep != nil {
+		c.sleep(d)
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause delays the new requests until d from now, if later than the
+// current pause.
+func (c *RetryClient) pause(d time.Duration) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t := time.Now().Add(d); t.After(c.until) {
+		c.until = t
+	}
+}
+
+// paused returns the time left before a new request can be sent.
+func (c *RetryClient) paused() time.Duration {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Until(c.until)
+}
+
+// Do sends the request retrying it according to the policy.
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+
+	ctx := req.Context()
+
+	if d := c.paused(); d > 0 {
+		if err := c.wait(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+
+	var waited time.Duration
+
+	attempt := req
+
+	for n := 0; ; n++ {
+		resp, err := c.Client.Do(attempt)
+
+		if n >= c.MaxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		// a request with a body can be sent again only if the body can be rebuilt.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		d := c.backoff(n, resp)
+
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			c.pause(d)
+		}
+
+		if waited+d > c.MaxWait {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if err := c.wait(ctx, d); err != nil {
+			return nil, err
+		}
+		waited += d
+
+		attempt = req.Clone(ctx)
+
+		if req.GetBody != nil {
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package tfcloud
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+// newTestRetryClient returns a retry client over the mock client that
+// records the waits instead of sleeping.
+func newTestRetryClient(waits *[]time.Duration) *RetryClient {
+
+	c := NewRetryClient(&mocks.MockClient{})
+	c.sleep = func(d time.Duration) {
+		*waits = append(*waits, d)
+	}
+
+	return c
+}
+
+func TestRetryRateLimited(t *testing.T) {
+
+	var waits []time.Duration
+	var bodies []string
+
+	DefaultHTTPClient = newTestRetryClient(&waits)
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+
+		if len(bodies) < 3 {
+			h := http.Header{}
+			h.Set("X-RateLimit-Reset", "0.25")
+
+			return &http.Response{
+				StatusCode: 429,
+				Header:     h,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+
+	p := newPayload("ws-test", Attributes{Category: "terraform", Key: "key", Value: "value"})
+
+	if err := p.Post("ws-test", ""); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	expected := fmt.Sprint([]time.Duration{250 * time.Millisecond, 250 * time.Millisecond})
+
+	if expected != fmt.Sprint(waits) {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, waits))
+		t.Fail()
+	}
+
+	if len(bodies) != 3 || bodies[0] != bodies[2] || bodies[2] == "" {
+		t.Log(fmt.Printf("error expected the same body on every attempt actual %v", bodies))
+		t.Fail()
+	}
+}
+
+func TestRetryServerErrorIdempotentOnly(t *testing.T) {
+
+	var waits []time.Duration
+
+	DefaultHTTPClient = newTestRetryClient(&waits)
+
+	requests := 0
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		requests++
+
+		return &http.Response{
+			StatusCode: 503,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+
+	p := newPayload("ws-test", Attributes{Category: "terraform", Key: "key", Value: "value"})
+
+	if err := p.Post("ws-test", ""); err == nil || requests != 1 {
+		t.Log(fmt.Printf("error expected a POST not to be retried: %d requests", requests))
+		t.Fail()
+	}
+
+	requests = 0
+
+	v := TerraformVars{}
+
+	if err := v.Get("ws-test", ""); err == nil || requests != DEFAULT_MAX_RETRIES+1 {
+		t.Log(fmt.Printf("error expected a GET to be retried: %d requests", requests))
+		t.Fail()
+	}
+
+	expected := fmt.Sprint([]time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+	})
+
+	if expected != fmt.Sprint(waits) {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, waits))
+		t.Fail()
+	}
+}
+
+func TestRetryMaxWait(t *testing.T) {
+
+	var waits []time.Duration
+
+	c := newTestRetryClient(&waits)
+	c.MaxWait = 5 * time.Second
+	DefaultHTTPClient = c
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		h := http.Header{}
+		h.Set("Retry-After", "3")
+
+		return &http.Response{
+			StatusCode: 429,
+			Header:     h,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+
+	if err := v.Get("ws-test", ""); err == nil || statusCode(err) != 429 {
+		t.Log(fmt.Printf("error expected 429 actual %v", err))
+		t.Fail()
+	}
+
+	if len(waits) != 1 {
+		t.Log(fmt.Printf("error expected one wait actual %v", waits))
+		t.Fail()
+	}
+}
+
+func TestRetryWaitCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+
+	c := NewRetryClient(&mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		attempts++
+		cancel()
+
+		h := http.Header{}
+		h.Set("Retry-After", "60")
+
+		return &http.Response{
+			StatusCode: 429,
+			Header:     h,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}})
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://app.terraform.io/api/v2/workspaces/ws-test/vars", nil)
+
+	start := time.Now()
+
+	_, err := c.Do(req)
+
+	if err != context.Canceled {
+		t.Log(fmt.Printf("error expected %v actual %v", context.Canceled, err))
+		t.Fail()
+	}
+
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Log(fmt.Printf("error expected no retry after the cancellation actual %d attempts", attempts))
+		t.Fail()
+	}
+}
+
+func TestRetryPauseShared(t *testing.T) {
+
+	var waits []time.Duration
+
+	c := newTestRetryClient(&waits)
+
+	requests := 0
+
+	c.Client = &mocks.MockClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+
+		requests++
+
+		if requests == 1 {
+			h := http.Header{}
+			h.Set("Retry-After", "30")
+
+			return &http.Response{
+				StatusCode: 429,
+				Header:     h,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "https://app.terraform.io/api/v2/workspaces/ws-test/vars", nil)
+
+		if _, err := c.Do(req); err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+	}
+
+	// the second request waits for the pause asked by the rate limiter to the first one.
+	if len(waits) != 2 || waits[0] != 30*time.Second || waits[1] < 29*time.Second {
+		t.Log(fmt.Printf("error expected the second request to wait actual %v", waits))
+		t.Fail()
+	}
+}
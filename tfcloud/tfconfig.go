@@ -1,16 +1,28 @@
 package tfcloud
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
 )
 
+type TfCredential struct {
+	Token string `json:"token"`
+}
+
+// TfConfig is the terraform credentials storage file: credentials.tfrc.json
+// The credentials are keyed by hostname.
 type TfConfig struct {
-	Credentials struct {
-		App_terraform_io struct {
-			Token string `json:"token"`
-		} `json:"app.terraform.io"`
-	} `json:"credentials"`
+	Credentials map[string]TfCredential `json:"credentials"`
 }
 
 func (c *TfConfig) Read(fileName string) (err error) {
@@ -24,3 +36,249 @@ func (c *TfConfig) Read(fileName string) (err error) {
 
 	return nil
 }
+
+// Token returns the token stored for the host.
+func (c *TfConfig) Token(host string) (token string, found bool) {
+
+	cred, found := c.Credentials[host]
+
+	return cred.Token, found
+}
+
+// CliConfig is the terraform cli configuration file: ~/.terraformrc or the
+// file set by the env variable TF_CLI_CONFIG_FILE.
+// Only the credentials and credentials_helper blocks are read.
+type CliConfig struct {
+	Credentials map[string]TfCredential
+	// Helper is the name of the credentials helper program
+	// terraform-credentials-<name>, HelperArgs its arguments.
+	Helper     string
+	HelperArgs []string
+}
+
+var cliConfigSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "credentials", LabelNames: []string{"host"}},
+		{Type: "credentials_helper", LabelNames: []string{"name"}},
+	},
+}
+
+// Read decodes the hcl, or json if the file name ends with .json, cli configuration file.
+func (c *CliConfig) Read(fileName string) (err error) {
+
+	p := hclparse.NewParser()
+
+	var f *hcl.File
+	var diags hcl.Diagnostics
+
+	if strings.HasSuffix(fileName, ".json") {
+		f, diags = p.ParseJSONFile(fileName)
+	} else {
+		f, diags = p.ParseHCLFile(fileName)
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	content, _, diags := f.Body.PartialContent(cliConfigSchema)
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	c.Credentials = map[string]TfCredential{}
+
+	for _, b := range content.Blocks {
+		switch b.Type {
+		case "credentials":
+			cred := struct {
+				Token  string   `hcl:"token,optional"`
+				Remain hcl.Body `hcl:",remain"`
+			}{}
+
+			if diags := gohcl.DecodeBody(b.Body, nil, &cred); diags.HasErrors() {
+				return diags
+			}
+
+			c.Credentials[strings.ToLower(b.Labels[0])] = TfCredential{Token: cred.Token}
+		case "credentials_helper":
+			if c.Helper != "" {
+				return fmt.Errorf("%s: only one credentials_helper block is allowed", fileName)
+			}
+
+			helper := struct {
+				Args []string `hcl:"args,optional"`
+			}{}
+
+			if diags := gohcl.DecodeBody(b.Body, nil, &helper); diags.HasErrors() {
+				return diags
+			}
+
+			c.Helper, c.HelperArgs = b.Labels[0], helper.Args
+		}
+	}
+
+	return nil
+}
+
+// HostEnvName returns the name of the env variable with the token of the
+// host, as encoded by terraform: TF_TOKEN_ followed by the hostname with
+// the dots replaced by _ and the hyphens by __, eg:
+// TF_TOKEN_app_terraform_io
+func HostEnvName(host string) string {
+	return "TF_TOKEN_" + strings.NewReplacer("-", "__", ".", "_").Replace(host)
+}
+
+// TokenFromEnv returns the token of the host set in the env variable
+// TF_TOKEN_<host>. The hostname is compared ignoring the case.
+func TokenFromEnv(host string) (token string, name string, found bool) {
+
+	for _, e := range os.Environ() {
+		kv := strings.SplitN(e, "=", 2)
+
+		if len(kv) == 2 && strings.EqualFold(kv[0], HostEnvName(host)) && kv[1] != "" {
+			return kv[1], kv[0], true
+		}
+	}
+
+	return "", "", false
+}
+
+// CredentialsChain looks up the token of a host the way the terraform cli
+// does, so the token works wherever terraform login works.
+type CredentialsChain struct {
+	// CliConfigFile is the terraform cli configuration file.
+	CliConfigFile string
+	// CredentialsFile is the credentials storage file written by terraform login.
+	CredentialsFile string
+	// PluginDirs are the directories searched for the credentials helper
+	// before the PATH.
+	PluginDirs []string
+}
+
+// DefaultCredentialsChain returns the chain of the terraform cli default files:
+// TF_CLI_CONFIG_FILE or ~/.terraformrc and ~/.terraform.d/credentials.tfrc.json
+func DefaultCredentialsChain() CredentialsChain {
+
+	home, _ := os.UserHomeDir()
+
+	c := CredentialsChain{
+		CliConfigFile:   filepath.Join(home, ".terraformrc"),
+		CredentialsFile: filepath.Join(home, ".terraform.d", "credentials.tfrc.json"),
+		PluginDirs: []string{
+			filepath.Join(home, ".terraform.d", "plugins"),
+			filepath.Join(home, ".local", "share", "terraform", "plugins"),
+		},
+	}
+
+	if f, ok := os.LookupEnv("TF_CLI_CONFIG_FILE"); ok {
+		c.CliConfigFile = f
+	}
+
+	return c
+}
+
+// Token returns the token of the host and a description of its source.
+// The sources are checked in order:
+// the env variable TF_TOKEN_<host>, the credentials blocks of the cli
+// configuration file, the credentials storage file and the credentials
+// helper of the cli configuration file.
+// found is false, with a nil error, when no source has a token for the host.
+func (c *CredentialsChain) Token(host string) (token string, source string, found bool, err error) {
+
+	host = strings.ToLower(host)
+
+	if token, name, found := TokenFromEnv(host); found {
+		return token, "env variable " + name, true, nil
+	}
+
+	cli := CliConfig{}
+
+	if err := cli.Read(c.CliConfigFile); err != nil && !isNotExist(c.CliConfigFile) {
+		return "", "", false, err
+	}
+
+	if cred, ok := cli.Credentials[host]; ok && cred.Token != "" {
+		return cred.Token, "credentials block of " + c.CliConfigFile, true, nil
+	}
+
+	stored := TfConfig{}
+
+	if err := stored.Read(c.CredentialsFile); err == nil {
+		if token, found := stored.Token(host); found && token != "" {
+			return token, c.CredentialsFile, true, nil
+		}
+	}
+
+	if cli.Helper == "" {
+		return "", "", false, nil
+	}
+
+	prog, err := c.findHelper(cli.Helper)
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	token, err = runHelper(prog, cli.HelperArgs, host)
+
+	if err != nil || token == "" {
+		return "", "", false, err
+	}
+
+	return token, "credentials helper " + prog, true, nil
+}
+
+// findHelper returns the path of the credentials helper program
+// terraform-credentials-<name>.
+func (c *CredentialsChain) findHelper(name string) (prog string, err error) {
+
+	prog = "terraform-credentials-" + name
+
+	for _, dir := range c.PluginDirs {
+		p := filepath.Join(dir, prog)
+
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p, nil
+		}
+	}
+
+	p, err := exec.LookPath(prog)
+
+	if err != nil {
+		return "", fmt.Errorf("credentials helper %s not found", prog)
+	}
+
+	return p, nil
+}
+
+// runHelper runs the credentials helper with the arguments: get <host>.
+// The helper prints a json object with the token, or an empty object
+// when it has no token for the host.
+func runHelper(prog string, args []string, host string) (token string, err error) {
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(prog, append(append([]string{}, args...), "get", host)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("credentials helper %s: %s %s", prog, err, strings.TrimSpace(stderr.String()))
+	}
+
+	cred := TfCredential{}
+
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return "", fmt.Errorf("credentials helper %s: invalid response: %s", prog, err)
+	}
+
+	return cred.Token, nil
+}
+
+// isNotExist reports whether the file does not exist.
+func isNotExist(fileName string) bool {
+	_, err := os.Stat(fileName)
+	return os.IsNotExist(err)
+}
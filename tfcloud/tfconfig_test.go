@@ -2,6 +2,11 @@ package tfcloud
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -16,7 +21,7 @@ func TestValidConfig(t *testing.T) {
 	}
 
 	expected := "this.is.a.test"
-	actual := c.Credentials.App_terraform_io.Token
+	actual, _ := c.Token("app.terraform.io")
 
 	if expected != actual {
 		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
@@ -35,3 +40,161 @@ func TestConfigFileNotFound(t *testing.T) {
 	}
 
 }
+
+func TestConfigCustomHost(t *testing.T) {
+
+	c := TfConfig{}
+	err := c.Read("./mocks/credentials.tfrc.json")
+
+	if err != nil {
+		t.Log(fmt.Printf("error reading config file %t", err))
+		t.Fail()
+	}
+
+	expected := "this.is.a.tfe.test"
+	actual, found := c.Token("tfe.example.com")
+
+	if !found || expected != actual {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+		t.Fail()
+	}
+
+	if _, found := c.Token("missing.example.com"); found {
+		t.Log("error expected no token for missing.example.com")
+		t.Fail()
+	}
+}
+
+func TestReadCliConfig(t *testing.T) {
+
+	c := CliConfig{}
+
+	if err := c.Read("./mocks/terraformrc"); err != nil {
+		t.Log(fmt.Printf("error reading cli config file %v", err))
+		t.FailNow()
+	}
+
+	expected := map[string]string{
+		"app.terraform.io": "this.is.a.cli.test",
+		"tfe.example.com":  "this.is.a.cli.tfe.test",
+	}
+
+	for host, token := range expected {
+		if c.Credentials[host].Token != token {
+			t.Log(fmt.Printf("error expected %s actual %s", token, c.Credentials[host].Token))
+			t.Fail()
+		}
+	}
+
+	if c.Helper != "mock" || len(c.HelperArgs) != 1 || c.HelperArgs[0] != "-v" {
+		t.Log(fmt.Printf("error unexpected helper %s %v", c.Helper, c.HelperArgs))
+		t.Fail()
+	}
+}
+
+func TestHostEnvName(t *testing.T) {
+
+	cases := map[string]string{
+		"app.terraform.io":     "TF_TOKEN_app_terraform_io",
+		"tfe-prod.example.com": "TF_TOKEN_tfe__prod_example_com",
+		"xn--caf-dma.fr":       "TF_TOKEN_xn____caf__dma_fr",
+	}
+
+	for host, expected := range cases {
+		if actual := HostEnvName(host); expected != actual {
+			t.Log(fmt.Printf("error expected %s actual %s", expected, actual))
+			t.Fail()
+		}
+	}
+}
+
+// writeHelper writes a credentials helper that prints the tokens of
+// app.terraform.io and fails for fail.example.com.
+func writeHelper(t *testing.T, dir string) {
+
+	script := `#!/bin/sh
+[ "$1" = "-v" ] && [ "$2" = "get" ] || exit 2
+case "$3" in
+  app.terraform.io) echo '{"token": "this.is.a.helper.test"}' ;;
+  fail.example.com) echo "no access" >&2; exit 1 ;;
+  *) echo '{}' ;;
+esac
+`
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "terraform-credentials-mock"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCredentialsChain(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the mock credentials helper is a shell script")
+	}
+
+	dir := t.TempDir()
+	writeHelper(t, dir)
+
+	c := CredentialsChain{
+		CliConfigFile:   "./mocks/terraformrc",
+		CredentialsFile: "./mocks/credentials.tfrc.json",
+		PluginDirs:      []string{dir},
+	}
+
+	os.Setenv("TF_TOKEN_tfe__prod_example_com", "this.is.an.env.test")
+	defer os.Unsetenv("TF_TOKEN_tfe__prod_example_com")
+
+	cases := []struct {
+		host   string
+		token  string
+		source string
+	}{
+		{"tfe-prod.example.com", "this.is.an.env.test", "env variable TF_TOKEN_tfe__prod_example_com"},
+		{"app.terraform.io", "this.is.a.cli.test", "credentials block of ./mocks/terraformrc"},
+		{"tfe.example.com", "this.is.a.cli.tfe.test", "credentials block of ./mocks/terraformrc"},
+		{"missing.example.com", "", ""},
+	}
+
+	for _, e := range cases {
+		token, source, found, err := c.Token(e.host)
+
+		if err != nil || found != (e.token != "") || token != e.token || source != e.source {
+			t.Log(fmt.Printf("error %s: expected %s from %s actual %s from %s %v", e.host, e.token, e.source, token, source, err))
+			t.Fail()
+		}
+	}
+
+	// without credentials blocks the storage file and then the helper are used.
+	rc := filepath.Join(dir, "terraformrc")
+	ioutil.WriteFile(rc, []byte(`credentials_helper "mock" { args = ["-v"] }`), 0600)
+	c.CliConfigFile = rc
+
+	token, source, _, err := c.Token("tfe.example.com")
+
+	if err != nil || token != "this.is.a.tfe.test" || source != "./mocks/credentials.tfrc.json" {
+		t.Log(fmt.Printf("error expected the storage file token actual %s from %s %v", token, source, err))
+		t.Fail()
+	}
+
+	c.CredentialsFile = filepath.Join(dir, "missing.tfrc.json")
+
+	token, source, _, err = c.Token("app.terraform.io")
+
+	if err != nil || token != "this.is.a.helper.test" || source != "credentials helper "+filepath.Join(dir, "terraform-credentials-mock") {
+		t.Log(fmt.Printf("error expected the helper token actual %s from %s %v", token, source, err))
+		t.Fail()
+	}
+
+	if _, _, _, err := c.Token("fail.example.com"); err == nil || !strings.Contains(err.Error(), "no access") {
+		t.Log(fmt.Printf("error expected the helper error actual %v", err))
+		t.Fail()
+	}
+
+	// without any file there is no token and no error.
+	c = CredentialsChain{CliConfigFile: filepath.Join(dir, "missing"), CredentialsFile: filepath.Join(dir, "missing.json")}
+
+	if _, _, found, err := c.Token("app.terraform.io"); found || err != nil {
+		t.Log(fmt.Printf("error expected no token actual %t %v", found, err))
+		t.Fail()
+	}
+}
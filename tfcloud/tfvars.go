@@ -1,20 +1,25 @@
 package tfcloud
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"strconv"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
 const (
-	TF_CLOUD_URL = "https://app.terraform.io/api/v2/workspaces/%s/vars"
-	BEARER_TOKEN = "Bearer %s"
-	CONTENT_TYPE = "application/vnd.api+json"
+	DEFAULT_HOST     = "app.terraform.io"
+	TF_CLOUD_URL     = "/api/v2/workspaces/%s/vars"
+	TF_CLOUD_VAR_URL = "/api/v2/workspaces/%s/vars/%s"
+	BEARER_TOKEN     = "Bearer %s"
+	CONTENT_TYPE     = "application/vnd.api+json"
 )
 
 type Attributes struct {
@@ -61,6 +66,11 @@ type TerraformVars struct {
 	Data []Data `json:"data"`
 }
 
+// VarResponse is the variable returned by the api when it is created.
+type VarResponse struct {
+	Data Data `json:"data"`
+}
+
 type Workspace struct {
 	Data ConfigData `json:"data"`
 }
@@ -71,6 +81,7 @@ type PayloadRelationships struct {
 
 type PayloadData struct {
 	Attributes    `json:"attributes"`
+	ID            string               `json:"id,omitempty"`
 	Relationships PayloadRelationships `json:"relationships"`
 	Type          string               `json:"type"`
 }
@@ -85,11 +96,16 @@ type HTTPClient interface {
 }
 
 var (
-	Client HTTPClient
+	// DefaultHTTPClient sends the requests of the package level functions.
+	DefaultHTTPClient HTTPClient
+	// Host is the hostname of the Terraform Cloud or Enterprise api
+	// used by the package level functions.
+	Host string
 )
 
 func init() {
-	Client = &http.Client{}
+	DefaultHTTPClient = NewRetryClient(&http.Client{})
+	Host = DEFAULT_HOST
 }
 
 func tojson(data interface{}, indent bool) (ret string, err error) {
@@ -115,185 +131,323 @@ func (v *Payload) Json(indent bool) (data string, err error) {
 	return tojson(v, indent)
 }
 
-func cleanHcl(data string) string {
-
-	data = strings.ReplaceAll(data, "\"{", "{")
-	data = strings.ReplaceAll(data, "}\"", "}")
-	data = strings.ReplaceAll(data, "\\\"", "\"")
-	data = strings.ReplaceAll(data, "\"[", "[")
-	data = strings.ReplaceAll(data, "]\"", "]")
-	data = strings.ReplaceAll(data, "\\n", "")
-
-	return data
-}
-
+// ToTfVars renders the terraform category variables as an hcl tfvars file.
+// Sensitive variables are written as comments because their value is not readable.
+// When indent is true the file is formatted aligning the equal signs.
 func (v *TerraformVars) ToTfVars(indent bool) (data string, err error) {
 
-	t := make(map[string]interface{})
+	f := hclwrite.NewEmptyFile()
+
+	vars := v.Filter(func(d Data) bool {
+		return d.Category != "env"
+	})
 
-	for _, v := range v.Data {
+	sort.SliceStable(vars.Data, func(i, j int) bool {
+		return vars.Data[i].Key < vars.Data[j].Key
+	})
 
-		if v.Sensitive == true {
-			t[v.Key] = "sensitive"
-		} else {
-			t[v.Key] = stringToJsonType(v.Value)
+	for _, d := range vars.Data {
+		if err := setTfVar(f.Body(), d.Attributes); err != nil {
+			return "", err
 		}
+	}
 
+	if indent {
+		return string(hclwrite.Format(f.Bytes())), nil
 	}
 
-	var byteArray []byte
+	return string(f.Bytes()), nil
+}
 
-	if indent == true {
-		byteArray, err = json.MarshalIndent(t, "  ", "  ")
-	} else {
-		byteArray, err = json.Marshal(t)
+// Load TerraformVars from a file.
+// .tfvars and .tfvars.json files are loaded as terraform category variables,
+// .env files as env category variables and any other file must be in the
+// json format returned by Get.
+func (v *TerraformVars) Load(fileName string) (err error) {
+
+	if IsTfVars(fileName) {
+		return v.LoadTfVars(fileName, "terraform")
+	}
+
+	if IsDotEnv(fileName) {
+		return v.LoadDotEnv(fileName)
 	}
 
+	jsonFile, err := ioutil.ReadFile(fileName)
+
 	if err != nil {
-		return "", fmt.Errorf("[ERROR] Marshalng map to json %t", err)
+		return err
 	}
 
-	data = string(byteArray)
-	data = cleanHcl(data)
+	// fmt.Println(string(jsonFile))
 
-	return
+	json.Unmarshal([]byte(jsonFile), &v)
 
+	return nil
 }
 
-func (v *TerraformVars) Get(w string, t string) (err error) {
+// IsTfVars reports whether the file is a .tfvars or .tfvars.json file.
+func IsTfVars(fileName string) bool {
+	return strings.HasSuffix(fileName, ".tfvars") || strings.HasSuffix(fileName, ".tfvars.json")
+}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf(TF_CLOUD_URL, w), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", fmt.Sprintf(BEARER_TOKEN, t))
-	req.Header.Set("Content-Type", CONTENT_TYPE)
+// FileCategory returns the only category of the variables that Load reads
+// from the file: terraform for tfvars files and env for .env files.
+// It is empty for the json files, that can hold both categories.
+func FileCategory(fileName string) string {
 
-	resp, err := Client.Do(req)
-	if err != nil {
-		return err
+	switch {
+	case IsTfVars(fileName):
+		return "terraform"
+	case IsDotEnv(fileName):
+		return "env"
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Http request status code %d", resp.StatusCode)
-	}
+	return ""
+}
 
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-	respByte := buf.Bytes()
+// LoadTfVars loads the variables of a .tfvars or .tfvars.json file
+// in the category. Lists, maps and objects are loaded as hcl values.
+func (v *TerraformVars) LoadTfVars(fileName string, category string) (err error) {
+
+	src, err := ioutil.ReadFile(fileName)
 
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(respByte, &v); err != nil {
-		return err
-	}
+	v.Data, err = parseTfVars(src, fileName, strings.HasSuffix(fileName, ".json"), category)
 
-	return nil
+	return err
 }
 
-// Load TerraformVars from a json file
-func (v *TerraformVars) Load(fileName string) (err error) {
+// newPayload builds the payload to create or update the variable a in the workspace w.
+func newPayload(w string, a Attributes) Payload {
+	p := Payload{}
+	p.Data.Type = "vars"
+	p.Data.Attributes.Key = a.Key
+	p.Data.Attributes.Value = a.Value
+	p.Data.Attributes.Description = a.Description
+	p.Data.Attributes.Category = a.Category
+	p.Data.Attributes.Hcl = a.Hcl
+	p.Data.Attributes.Sensitive = a.Sensitive
+	p.Data.Relationships.Workspace.Data.ID = w
+	p.Data.Relationships.Workspace.Data.Type = "workspaces"
+
+	return p
+}
 
-	jsonFile, err := ioutil.ReadFile(fileName)
+// Find returns the variable with the given key and category.
+func (v *TerraformVars) Find(key string, category string) (d Data, found bool) {
 
-	if err != nil {
-		return err
+	for _, d := range v.Data {
+		if d.Key == key && d.Category == category {
+			return d, true
+		}
 	}
 
-	// fmt.Println(string(jsonFile))
+	return Data{}, false
+}
 
-	json.Unmarshal([]byte(jsonFile), &v)
+// Lookup returns the variable with the key in the category or, when the
+// category is empty, in any category. It fails when the variable is not
+// defined or, with an empty category, when it is defined in both categories.
+func (v *TerraformVars) Lookup(key string, category string) (d Data, err error) {
 
-	return nil
+	var found []Data
+
+	for _, d := range v.Data {
+		if d.Key == key && (category == "" || d.Category == category) {
+			found = append(found, d)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return d, fmt.Errorf("variable %q not found", key)
+	case 1:
+		return found[0], nil
+	}
+
+	return d, fmt.Errorf("variable %q is defined in more categories: set the category", key)
 }
 
-// Post the payload to the terraform cloud api that creates the variable.
-// w is the workspace
-// t is the bearer token
-func (p *Payload) Post(w string, t string) (err error) {
-	var resp *http.Response
-	jsonPayload, err := p.Json(false)
+// Filter returns the variables of v for which keep returns true.
+func (v *TerraformVars) Filter(keep func(d Data) bool) TerraformVars {
 
-	if err != nil {
-		log.Println(err)
+	f := TerraformVars{}
+
+	for _, d := range v.Data {
+		if keep(d) {
+			f.Data = append(f.Data, d)
+		}
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf(TF_CLOUD_URL, w),
-		bytes.NewBuffer([]byte(jsonPayload)))
+	return f
+}
 
-	if err != nil {
-		return err
+// Select returns the variables of v whose key is one of keys, matches the
+// glob pattern or the regular expression expr, matched against the whole key.
+// When category is not empty only the variables of the category are selected.
+// At least one of keys, pattern and expr is required, so that Select
+// never returns all the variables by mistake.
+func (v *TerraformVars) Select(keys []string, pattern string, expr string, category string) (s TerraformVars, err error) {
+
+	if len(keys) == 0 && pattern == "" && expr == "" {
+		return s, fmt.Errorf("keys, pattern or regular expression required")
 	}
-	req.Header.Set("Authorization", fmt.Sprintf(BEARER_TOKEN, t))
-	req.Header.Set("Content-Type", CONTENT_TYPE)
 
-	resp, err = Client.Do(req)
+	var re *regexp.Regexp
 
-	if err != nil {
-		return err
+	if expr != "" {
+		if re, err = regexp.Compile("^(?:" + expr + ")$"); err != nil {
+			return s, err
+		}
 	}
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("Http request status code %d", resp.StatusCode)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return s, err
 	}
 
-	defer resp.Body.Close()
+	for _, d := range v.Data {
+		if category != "" && d.Category != category {
+			continue
+		}
 
-	return nil
+		match := false
 
-}
+		for _, k := range keys {
+			match = match || k == d.Key
+		}
 
-func (v *TerraformVars) Post(w string, t string) (err error) {
+		if pattern != "" {
+			m, _ := path.Match(pattern, d.Key)
+			match = match || m
+		}
 
-	for _, d := range v.Data {
-		p := Payload{}
-		p.Data.Type = "vars"
-		p.Data.Attributes.Key = d.Attributes.Key
-		p.Data.Attributes.Value = d.Attributes.Value
-		p.Data.Attributes.Description = d.Attributes.Description
-		p.Data.Attributes.Category = d.Attributes.Category
-		p.Data.Attributes.Hcl = d.Attributes.Hcl
-		p.Data.Attributes.Sensitive = d.Attributes.Sensitive
-		p.Data.Relationships.Workspace.Data.ID = w
-		p.Data.Relationships.Workspace.Data.Type = "workspaces"
-
-		err := p.Post(w, t)
-
-		if err != nil {
-			log.Println(fmt.Sprintf("%s %s", d.Attributes.Key, err))
-		} else {
-			log.Println(fmt.Sprintf("%s created.", d.Attributes.Key))
+		if re != nil {
+			match = match || re.MatchString(d.Key)
 		}
 
+		if match {
+			s.Data = append(s.Data, d)
+		}
 	}
 
-	return nil
+	return s, nil
 }
 
-func stringToJsonType(s string) interface{} {
+// Absent returns the variables of v whose key and category are not defined in o.
+func (v *TerraformVars) Absent(o *TerraformVars) []Data {
 
-	i, err := strconv.Atoi(s)
+	var absent []Data
 
-	if err == nil {
-		return i
+	for _, d := range v.Data {
+		if _, found := o.Find(d.Key, d.Category); !found {
+			absent = append(absent, d)
+		}
 	}
 
-	f, err := strconv.ParseFloat(s, 32)
+	return absent
+}
+
+// Stale returns the variables of current not defined in v: the ones a
+// prune deletes. When category is not empty only the variables of the
+// category are returned, so that a file holding a single category, eg:
+// a tfvars file, never deletes the variables of the other one.
+func (v *TerraformVars) Stale(current *TerraformVars, category string) []Data {
 
-	if err == nil {
-		return f
+	var stale []Data
+
+	for _, d := range current.Absent(v) {
+		if category == "" || d.Category == category {
+			stale = append(stale, d)
+		}
 	}
 
-	switch s {
-	case "true":
-		return true
-	case "false":
+	return stale
+}
+
+// unknownValue reports whether the value of a is not known: sensitive
+// values are write only, so the api and the files written by read leave them empty.
+func unknownValue(a Attributes) bool {
+	return a.Sensitive && a.Value == ""
+}
+
+// sameAttributes reports whether the variable b would leave a unchanged.
+// Sensitive values are write only, so they are never considered equal.
+func sameAttributes(a Attributes, b Attributes) bool {
+
+	if a.Sensitive || b.Sensitive {
 		return false
 	}
 
-	return s
+	return a.Value == b.Value &&
+		a.Description == b.Description &&
+		a.Hcl == b.Hcl
+}
+
+// Get all the variables of the workspace following the pagination links.
+// w is the workspace
+// t is the bearer token
+func (v *TerraformVars) Get(w string, t string) (err error) {
+
+	r, err := defaultClient(t).GetVars(context.Background(), w)
 
+	if err != nil {
+		return err
+	}
+
+	v.Data = r.Data
+
+	return nil
+}
+
+// Post the payload to the terraform cloud api that creates the variable.
+// w is the workspace
+// t is the bearer token
+func (p *Payload) Post(w string, t string) (err error) {
+	_, err = defaultClient(t).CreateVar(context.Background(), w, p)
+	return err
+}
+
+// Patch the payload to the terraform cloud api that updates an existing variable.
+// The variable id must be set in the payload data.
+// w is the workspace
+// t is the bearer token
+func (p *Payload) Patch(w string, t string) (err error) {
+	return defaultClient(t).UpdateVar(context.Background(), w, p)
+}
+
+// Delete the variable from the workspace.
+// w is the workspace
+// t is the bearer token
+func (d *Data) Delete(w string, t string) (err error) {
+	return defaultClient(t).DeleteVar(context.Background(), w, d.ID)
+}
+
+// Post creates all the variables of v in the workspace.
+// The result reports the outcome of every variable, the error combines
+// the errors of the failed ones as KeyErrors.
+// w is the workspace
+// t is the bearer token
+func (v *TerraformVars) Post(w string, t string) (r Result, err error) {
+	return defaultClient(t).PostVars(context.Background(), w, v)
+}
+
+// Upsert creates the variables missing in the workspace and updates the
+// ones that already exist with different attributes.
+// w is the workspace
+// t is the bearer token
+func (v *TerraformVars) Upsert(w string, t string) (r Result, err error) {
+	return defaultClient(t).UpsertVars(context.Background(), w, v)
+}
+
+// Delete removes all the variables of v from the workspace.
+// The variable ids must be the ones returned by Get.
+// The error combines the errors of the failed variables as KeyErrors.
+// w is the workspace
+// t is the bearer token
+func (v *TerraformVars) Delete(w string, t string) (r Result, err error) {
+	return defaultClient(t).DeleteVars(context.Background(), w, v)
 }
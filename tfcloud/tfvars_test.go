@@ -2,6 +2,7 @@ package tfcloud
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -12,7 +13,7 @@ import (
 
 func TestGetValidResponse(t *testing.T) {
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 
 	j := `{
 		"data": [
@@ -121,7 +122,7 @@ func TestGetValidResponse(t *testing.T) {
 
 func TestGetEmptyResponse(t *testing.T) {
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 
 	json := `{
 		"data": []
@@ -157,7 +158,7 @@ func TestGetEmptyResponse(t *testing.T) {
 
 func TestGet404Response(t *testing.T) {
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 
 	json := `{
 		"errors": [
@@ -315,7 +316,7 @@ func TestPostPayloadOK(t *testing.T) {
 		}
 	  }`
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 	r := ioutil.NopCloser(bytes.NewReader([]byte(j)))
 
 	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
@@ -370,7 +371,7 @@ func TestPostKeyAlreadyExist(t *testing.T) {
 		]
 	  }`
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 	r := ioutil.NopCloser(bytes.NewReader([]byte(j)))
 
 	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
@@ -410,6 +411,50 @@ func TestPostKeyAlreadyExist(t *testing.T) {
 
 }
 
+func TestPostResult(t *testing.T) {
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		p := Payload{}
+		b, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(b, &p)
+
+		if p.Data.Attributes.Key == "taken" {
+			return &http.Response{
+				StatusCode: 422,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"errors": [{"status": "422", "detail": "Key has already been taken"}]}`))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"data": {"id": "var-new", "type": "vars"}}`))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "new", Value: "1"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "taken", Value: "2"}},
+	)
+
+	r, err := v.Post("ws-test", "")
+
+	if r.Count(StatusCreated) != 1 || r.Keys[0].ID != "var-new" || r.Count(StatusFailed) != 1 {
+		t.Log(fmt.Printf("error unexpected result %v", r.Keys))
+		t.Fail()
+	}
+
+	e, ok := err.(KeyErrors)
+
+	if !ok || len(e) != 1 || e[0].Key != "taken" || !IsConflict(e[0].Err) {
+		t.Log(fmt.Printf("error expected the conflict of the key taken actual %v", err))
+		t.Fail()
+	}
+}
+
 func TestPostWorkspaceNotFound(t *testing.T) {
 
 	j := `{
@@ -421,7 +466,7 @@ func TestPostWorkspaceNotFound(t *testing.T) {
 		]
 	  }`
 
-	Client = &mocks.MockClient{}
+	DefaultHTTPClient = &mocks.MockClient{}
 	r := ioutil.NopCloser(bytes.NewReader([]byte(j)))
 
 	mocks.GetDoFunc = func(*http.Request) (*http.Response, error) {
@@ -457,7 +502,7 @@ func TestPostWorkspaceNotFound(t *testing.T) {
 	if err == nil {
 		t.Log(err)
 		t.Fail()
-	} else if err.Error() != "Http request status code 404" {
+	} else if !IsNotFound(err) || err.Error() != "Http request status code 404: not found" {
 		t.Log("Expected error 404")
 		t.Fail()
 	}
@@ -506,7 +551,7 @@ func TestFileNotFound(t *testing.T) {
 	}
 }
 
-func TestToTfVarsJsonHcl(t *testing.T) {
+func TestToTfVarsHcl(t *testing.T) {
 	v := TerraformVars{}
 
 	v.Data = append(v.Data, Data{ID: "var-a12eqwq", Type: "vars", Attributes: Attributes{
@@ -521,7 +566,7 @@ func TestToTfVarsJsonHcl(t *testing.T) {
 		Self: "/api/v2/workspaces/ws-xxxxxx/vars/var-xxxxxx",
 	}})
 
-	expected := "{\"cidr_subnet\":[\"10.0.5.0/24\"]}"
+	expected := "cidr_subnet = [\"10.0.5.0/24\"]\n"
 	actual, _ := v.ToTfVars(false)
 
 	if expected != actual {
@@ -530,7 +575,7 @@ func TestToTfVarsJsonHcl(t *testing.T) {
 	}
 }
 
-func TestToTfVarsJsonStr(t *testing.T) {
+func TestToTfVarsStr(t *testing.T) {
 	v := TerraformVars{}
 
 	v.Data = append(v.Data, Data{ID: "var-a12eqwq", Type: "vars", Attributes: Attributes{
@@ -545,7 +590,7 @@ func TestToTfVarsJsonStr(t *testing.T) {
 		Self: "/api/v2/workspaces/ws-xxxxxx/vars/var-xxxxxx",
 	}})
 
-	expected := "{\"name\":\"api\"}"
+	expected := "name = \"api\"\n"
 	actual, _ := v.ToTfVars(false)
 
 	if expected != actual {
@@ -554,7 +599,7 @@ func TestToTfVarsJsonStr(t *testing.T) {
 	}
 }
 
-func TestToTfVarsJsonNumber(t *testing.T) {
+func TestToTfVarsNumber(t *testing.T) {
 	v := TerraformVars{}
 
 	v.Data = append(v.Data, Data{ID: "var-a12eqwq", Type: "vars", Attributes: Attributes{
@@ -569,7 +614,7 @@ func TestToTfVarsJsonNumber(t *testing.T) {
 		Self: "/api/v2/workspaces/ws-xxxxxx/vars/var-xxxxxx",
 	}})
 
-	expected := "{\"count\":10}"
+	expected := "count = \"10\"\n"
 	actual, _ := v.ToTfVars(false)
 
 	if expected != actual {
@@ -577,3 +622,382 @@ func TestToTfVarsJsonNumber(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestUpsert(t *testing.T) {
+
+	j := `{
+		"data": [
+		  {
+			"id": "var-name",
+			"type": "vars",
+			"attributes": {
+			  "key": "name",
+			  "value": "api",
+			  "sensitive": false,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  },
+		  {
+			"id": "var-count",
+			"type": "vars",
+			"attributes": {
+			  "key": "count",
+			  "value": "1",
+			  "sensitive": false,
+			  "category": "terraform",
+			  "hcl": false
+			}
+		  }
+		]
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	patched := ""
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		switch req.Method {
+		case "GET":
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+			}, nil
+		case "PATCH":
+			patched = req.URL.Path
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{Attributes: Attributes{Category: "terraform", Key: "count", Value: "2"}},
+		Data{Attributes: Attributes{Category: "env", Key: "count", Value: "2"}},
+	)
+
+	r, err := v.Upsert("ws-test", "")
+
+	if err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	// the results are in the order of the keys: count env, count terraform, name.
+	expected := []Status{StatusCreated, StatusUpdated, StatusUnchanged}
+
+	for i, s := range expected {
+		if r.Keys[i].Status != s {
+			t.Log(fmt.Printf("error expected %s actual %s", s, r.Keys[i].Status))
+			t.Fail()
+		}
+	}
+
+	expected_path := "/api/v2/workspaces/ws-test/vars/var-count"
+
+	if expected_path != patched {
+		t.Log(fmt.Printf("error expected %s actual %s", expected_path, patched))
+		t.Fail()
+	}
+}
+
+func TestAbsent(t *testing.T) {
+
+	current := TerraformVars{}
+	current.Data = append(current.Data,
+		Data{ID: "var-name", Attributes: Attributes{Category: "terraform", Key: "name"}},
+		Data{ID: "var-old", Attributes: Attributes{Category: "terraform", Key: "old"}},
+		Data{ID: "var-env", Attributes: Attributes{Category: "env", Key: "name"}},
+	)
+
+	file := TerraformVars{}
+	file.Data = append(file.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name"}},
+	)
+
+	absent := current.Absent(&file)
+
+	expected := 2
+	actual := len(absent)
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %d actual %d", expected, actual))
+		t.FailNow()
+	}
+
+	if absent[0].ID != "var-old" || absent[1].ID != "var-env" {
+		t.Log(fmt.Printf("error unexpected variables %s %s", absent[0].ID, absent[1].ID))
+		t.Fail()
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		if req.URL.Path == "/api/v2/workspaces/ws-test/vars/var-missing" {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 204,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{ID: "var-old", Attributes: Attributes{Category: "terraform", Key: "old"}},
+		Data{ID: "var-missing", Attributes: Attributes{Category: "terraform", Key: "missing"}},
+	)
+
+	r, err := v.Delete("ws-test", "")
+
+	if r.Count(StatusDeleted) != 1 || r.Count(StatusFailed) != 1 {
+		t.Log(fmt.Printf("error unexpected result %v", r.Keys))
+		t.Fail()
+	}
+
+	if e, ok := err.(KeyErrors); !ok || len(e) != 1 || e[0].Key != "missing" || !IsNotFound(e[0].Err) {
+		t.Log(fmt.Printf("error expected the missing variable error actual %v", err))
+		t.Fail()
+	}
+}
+
+func TestFilter(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "terraform", Key: "name"}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_REGION"}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_SECRET_ACCESS_KEY", Sensitive: true}},
+	)
+
+	f := v.Filter(func(d Data) bool {
+		return d.Category == "env" && !d.Sensitive
+	})
+
+	expected := 1
+	actual := len(f.Data)
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %d actual %d", expected, actual))
+		t.FailNow()
+	}
+
+	expected_key := "AWS_REGION"
+	actual_key := f.Data[0].Key
+
+	if expected_key != actual_key {
+		t.Log(fmt.Printf("error expected %s actual %s", expected_key, actual_key))
+		t.Fail()
+	}
+}
+
+func TestLoadTfVars(t *testing.T) {
+	v := TerraformVars{}
+
+	err := v.Load("./mocks/terraform.tfvars")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := []Attributes{
+		{Category: "terraform", Key: "name", Value: "api"},
+		{Category: "terraform", Key: "replicas", Value: "3"},
+		{Category: "terraform", Key: "enabled", Value: "true"},
+		{Category: "terraform", Key: "cidr_subnet", Value: "[\"10.0.5.0/24\"]", Hcl: true},
+		{Category: "terraform", Key: "tags", Value: "{\n  env = \"prod\"\n}", Hcl: true},
+	}
+
+	if len(expected) != len(v.Data) {
+		t.Log(fmt.Printf("error expected %d actual %d", len(expected), len(v.Data)))
+		t.FailNow()
+	}
+
+	for i, a := range expected {
+		if a != v.Data[i].Attributes {
+			t.Log(fmt.Printf("error expected %v actual %v", a, v.Data[i].Attributes))
+			t.Fail()
+		}
+	}
+}
+
+func TestLoadTfVarsJson(t *testing.T) {
+	v := TerraformVars{}
+
+	err := v.LoadTfVars("./mocks/terraform.auto.tfvars.json", "env")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := 3
+	actual := len(v.Data)
+
+	if expected != actual {
+		t.Log(fmt.Printf("error expected %d actual %d", expected, actual))
+		t.FailNow()
+	}
+
+	expected_val := "[\"10.0.5.0/24\", \"10.0.6.0/24\"]"
+	actual_val := v.Data[2].Value
+
+	if expected_val != actual_val || !v.Data[2].Hcl || v.Data[2].Category != "env" {
+		t.Log(fmt.Printf("error expected %s actual %s", expected_val, actual_val))
+		t.Fail()
+	}
+}
+
+func TestSelect(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{ID: "var-a", Attributes: Attributes{Category: "terraform", Key: "feature_login"}},
+		Data{ID: "var-b", Attributes: Attributes{Category: "terraform", Key: "feature_search"}},
+		Data{ID: "var-c", Attributes: Attributes{Category: "env", Key: "feature_login"}},
+		Data{ID: "var-d", Attributes: Attributes{Category: "terraform", Key: "region"}},
+		Data{ID: "var-e", Attributes: Attributes{Category: "terraform", Key: "old_region"}},
+	)
+
+	cases := []struct {
+		keys     []string
+		pattern  string
+		expr     string
+		category string
+		expected []string
+	}{
+		{[]string{"region"}, "", "", "", []string{"var-d"}},
+		{nil, "feature_*", "", "", []string{"var-a", "var-b", "var-c"}},
+		{nil, "feature_*", "", "env", []string{"var-c"}},
+		{nil, "", "feature_(login|search)", "terraform", []string{"var-a", "var-b"}},
+		{nil, "", "region", "", []string{"var-d"}},
+		{[]string{"old_region"}, "", "feature_s.*", "", []string{"var-b", "var-e"}},
+		{[]string{"missing"}, "", "", "", nil},
+	}
+
+	for _, c := range cases {
+		s, err := v.Select(c.keys, c.pattern, c.expr, c.category)
+
+		if err != nil {
+			t.Log(err)
+			t.Fail()
+		}
+
+		actual := []string{}
+
+		for _, d := range s.Data {
+			actual = append(actual, d.ID)
+		}
+
+		if fmt.Sprint(c.expected) != fmt.Sprint(actual) {
+			t.Log(fmt.Printf("error %v %s %s expected %v actual %v", c.keys, c.pattern, c.expr, c.expected, actual))
+			t.Fail()
+		}
+	}
+
+	if _, err := v.Select(nil, "", "", "terraform"); err == nil {
+		t.Log("error expected a selection without keys to fail")
+		t.Fail()
+	}
+
+	if _, err := v.Select(nil, "[", "", ""); err == nil {
+		t.Log("error expected an invalid pattern to fail")
+		t.Fail()
+	}
+
+	if _, err := v.Select(nil, "", "(", ""); err == nil {
+		t.Log("error expected an invalid regular expression to fail")
+		t.Fail()
+	}
+}
+
+func TestLookup(t *testing.T) {
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{ID: "var-a", Attributes: Attributes{Category: "terraform", Key: "region", Value: "eu-south-1"}},
+		Data{ID: "var-b", Attributes: Attributes{Category: "terraform", Key: "name", Value: "api"}},
+		Data{ID: "var-c", Attributes: Attributes{Category: "env", Key: "name", Value: "API"}},
+	)
+
+	d, err := v.Lookup("region", "")
+
+	if err != nil || d.ID != "var-a" {
+		t.Log(fmt.Printf("error expected var-a actual %s %v", d.ID, err))
+		t.Fail()
+	}
+
+	d, err = v.Lookup("name", "env")
+
+	if err != nil || d.Value != "API" {
+		t.Log(fmt.Printf("error expected API actual %s %v", d.Value, err))
+		t.Fail()
+	}
+
+	if _, err := v.Lookup("name", ""); err == nil {
+		t.Log("error expected a key defined in both categories to fail")
+		t.Fail()
+	}
+
+	expected := `variable "missing" not found`
+
+	if _, err := v.Lookup("missing", ""); err == nil || err.Error() != expected {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, err))
+		t.Fail()
+	}
+}
+
+func TestStaleTfVars(t *testing.T) {
+
+	file := TerraformVars{}
+
+	if err := file.Load("./mocks/terraform.tfvars"); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	current := TerraformVars{Data: append([]Data{}, file.Data...)}
+	current.Data = append(current.Data,
+		Data{ID: "var-old", Attributes: Attributes{Category: "terraform", Key: "old"}},
+		Data{ID: "var-key", Attributes: Attributes{Category: "env", Key: "AWS_ACCESS_KEY_ID"}},
+	)
+
+	category := FileCategory("./mocks/terraform.tfvars")
+
+	stale := file.Stale(&current, category)
+
+	if category != "terraform" || len(stale) != 1 || stale[0].ID != "var-old" {
+		t.Log(fmt.Printf("error expected only var-old stale in %s actual %v", category, stale))
+		t.Fail()
+	}
+
+	p := file.Plan(&current, true, category)
+
+	if len(p.Changes) != 1 || p.Changes[0].Action != ActionDelete || p.Changes[0].Old.Key != "old" {
+		t.Log(fmt.Printf("error expected only old deleted actual %s", p.String()))
+		t.Fail()
+	}
+
+	if FileCategory("./mocks/aws.env") != "env" || FileCategory("./mocks/list.json") != "" {
+		t.Log("error unexpected file categories")
+		t.Fail()
+	}
+}
@@ -0,0 +1,335 @@
+package tfcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// GetVars reads all the variables of the workspace following the pagination links.
+// w is the workspace
+func (c *Client) GetVars(ctx context.Context, w string) (v TerraformVars, err error) {
+
+	err = c.getPages(ctx, c.url(TF_CLOUD_URL, w), func(body []byte) error {
+		p := TerraformVars{}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			return err
+		}
+
+		v.Data = append(v.Data, p.Data...)
+
+		return nil
+	})
+
+	return v, err
+}
+
+// CreateVar posts the payload that creates the variable in the workspace
+// and returns the variable created.
+// w is the workspace
+func (c *Client) CreateVar(ctx context.Context, w string, p *Payload) (d Data, err error) {
+
+	r := VarResponse{}
+
+	err = c.sendFor(ctx, "POST", c.url(TF_CLOUD_URL, w), p, http.StatusCreated, &r)
+
+	return r.Data, err
+}
+
+// UpdateVar patches the variable of the workspace with the payload.
+// The variable id must be set in the payload data.
+// w is the workspace
+func (c *Client) UpdateVar(ctx context.Context, w string, p *Payload) error {
+	return c.send(ctx, "PATCH", c.url(TF_CLOUD_VAR_URL, w, p.Data.ID), p, http.StatusOK)
+}
+
+// DeleteVar removes the variable with the id from the workspace.
+// w is the workspace
+func (c *Client) DeleteVar(ctx context.Context, w string, id string) error {
+	return c.send(ctx, "DELETE", c.url(TF_CLOUD_VAR_URL, w, id), nil, http.StatusNoContent)
+}
+
+// PostVars creates all the variables of v in the workspace.
+// The failures do not stop the other variables: their errors are
+// returned together as KeyErrors.
+// w is the workspace
+func (c *Client) PostVars(ctx context.Context, w string, v *TerraformVars) (r Result, err error) {
+	return c.create(ctx, workspaceVars{c, w}, v)
+}
+
+// UpsertVars creates the variables missing in the workspace and updates
+// the ones that already exist with different attributes.
+// The errors of the failed variables are returned together as KeyErrors.
+// w is the workspace
+func (c *Client) UpsertVars(ctx context.Context, w string, v *TerraformVars) (r Result, err error) {
+	return c.upsert(ctx, workspaceVars{c, w}, v)
+}
+
+// DeleteVars removes all the variables of v from the workspace.
+// The variable ids must be the ones returned by GetVars.
+// The errors of the failed variables are returned together as KeyErrors.
+// w is the workspace
+func (c *Client) DeleteVars(ctx context.Context, w string, v *TerraformVars) (r Result, err error) {
+	return c.delete(ctx, workspaceVars{c, w}, v)
+}
+
+// GetVar returns the variable of the workspace with the key in the category,
+// or in any category if empty.
+// w is the workspace
+func (c *Client) GetVar(ctx context.Context, w string, key string, category string) (Data, error) {
+	return c.lookup(ctx, workspaceVars{c, w}, key, category)
+}
+
+// SetVar creates the variable with the attributes a in the workspace, or
+// updates the one already defined with the same key and category.
+// w is the workspace
+func (c *Client) SetVar(ctx context.Context, w string, a Attributes) (KeyResult, error) {
+	return c.set(ctx, workspaceVars{c, w}, a)
+}
+
+// varsCollection is a list of variables managed through the api:
+// the variables of a workspace or of a variable set.
+type varsCollection interface {
+	list(ctx context.Context) (TerraformVars, error)
+	create(ctx context.Context, a Attributes) (id string, err error)
+	update(ctx context.Context, id string, a Attributes) error
+	remove(ctx context.Context, id string) error
+}
+
+// workspaceVars are the variables of the workspace with the given id.
+type workspaceVars struct {
+	c  *Client
+	id string
+}
+
+func (w workspaceVars) list(ctx context.Context) (TerraformVars, error) {
+	return w.c.GetVars(ctx, w.id)
+}
+
+func (w workspaceVars) create(ctx context.Context, a Attributes) (string, error) {
+	p := newPayload(w.id, a)
+	d, err := w.c.CreateVar(ctx, w.id, &p)
+	return d.ID, err
+}
+
+func (w workspaceVars) update(ctx context.Context, id string, a Attributes) error {
+	p := newPayload(w.id, a)
+	p.Data.ID = id
+	return w.c.UpdateVar(ctx, w.id, &p)
+}
+
+func (w workspaceVars) remove(ctx context.Context, id string) error {
+	return w.c.DeleteVar(ctx, w.id, id)
+}
+
+// logResult logs the outcome of the operation on a variable.
+func (c *Client) logResult(k KeyResult) {
+
+	if k.Err != nil {
+		c.log(fmt.Sprintf("%s %s", k.Key, k.Err))
+	} else {
+		c.log(fmt.Sprintf("%s %s.", k.Key, k.Status))
+	}
+}
+
+// each runs f on every variable of v, with at most c.workers calls at the
+// same time. The variables are processed in the order of their keys and
+// every result is logged as soon as it and the ones before it are done,
+// so the output does not depend on the scheduling. When the context is
+// cancelled the variables not processed yet are left out of the result
+// and the context error is returned, otherwise the errors of the failed variables.
+func (c *Client) each(ctx context.Context, v *TerraformVars, f func(d Data) KeyResult) (r Result, err error) {
+
+	workers := c.workers
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	order := make([]int, len(v.Data))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := v.Data[order[i]], v.Data[order[j]]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Category < b.Category
+	})
+
+	// done is the result of the variable i, nil if skipped.
+	type done struct {
+		i int
+		k *KeyResult
+	}
+
+	jobs := make(chan int)
+	results := make(chan done)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					results <- done{j, nil}
+					continue
+				}
+				k := f(v.Data[j])
+				results <- done{j, &k}
+			}
+		}()
+	}
+
+	go func() {
+		for _, i := range order {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	keys := make([]*KeyResult, len(v.Data))
+	finished := make([]bool, len(v.Data))
+	next := 0
+
+	for d := range results {
+		keys[d.i], finished[d.i] = d.k, true
+
+		for ; next < len(order) && finished[order[next]]; next++ {
+			if k := keys[order[next]]; k != nil {
+				c.logResult(*k)
+				r.Keys = append(r.Keys, *k)
+			}
+		}
+	}
+
+	if len(r.Keys) < len(v.Data) {
+		return r, ctx.Err()
+	}
+
+	return r, r.Err()
+}
+
+// create adds all the variables of v to the collection.
+func (c *Client) create(ctx context.Context, coll varsCollection, v *TerraformVars) (r Result, err error) {
+
+	return c.each(ctx, v, func(d Data) KeyResult {
+		k := KeyResult{Key: d.Key, Category: d.Category, Status: StatusCreated}
+
+		if k.ID, k.Err = coll.create(ctx, d.Attributes); k.Err != nil {
+			k.Status = StatusFailed
+		}
+
+		return k
+	})
+}
+
+// upsert adds the variables of v missing in the collection and updates
+// the ones that already exist with different attributes.
+func (c *Client) upsert(ctx context.Context, coll varsCollection, v *TerraformVars) (r Result, err error) {
+
+	current, err := coll.list(ctx)
+
+	if err != nil {
+		return r, err
+	}
+
+	return c.each(ctx, v, func(d Data) KeyResult {
+		return upsertKey(ctx, coll, &current, d.Attributes)
+	})
+}
+
+// upsertKey creates the variable a in the collection if missing in current,
+// the variables of the collection, or updates it if its attributes differ.
+// An existing variable is never updated with an unknown sensitive value,
+// that would replace the secret with an empty string.
+func upsertKey(ctx context.Context, coll varsCollection, current *TerraformVars, a Attributes) KeyResult {
+
+	k := KeyResult{Key: a.Key, Category: a.Category}
+
+	e, found := current.Find(a.Key, a.Category)
+
+	switch {
+	case !found:
+		k.Status = StatusCreated
+		k.ID, k.Err = coll.create(ctx, a)
+	case unknownValue(a), sameAttributes(e.Attributes, a):
+		k.Status = StatusUnchanged
+		k.ID = e.ID
+	default:
+		k.Status = StatusUpdated
+		k.ID = e.ID
+		k.Err = coll.update(ctx, e.ID, a)
+	}
+
+	if k.Err != nil {
+		k.Status = StatusFailed
+	}
+
+	return k
+}
+
+// delete removes all the variables of v from the collection.
+func (c *Client) delete(ctx context.Context, coll varsCollection, v *TerraformVars) (r Result, err error) {
+
+	return c.each(ctx, v, func(d Data) KeyResult {
+		k := KeyResult{Key: d.Key, Category: d.Category, ID: d.ID, Status: StatusDeleted}
+
+		if k.Err = coll.remove(ctx, d.ID); k.Err != nil {
+			k.Status = StatusFailed
+		}
+
+		return k
+	})
+}
+
+// lookup returns the variable of the collection with the key in the
+// category, or in any category if empty.
+func (c *Client) lookup(ctx context.Context, coll varsCollection, key string, category string) (Data, error) {
+
+	v, err := coll.list(ctx)
+
+	if err != nil {
+		return Data{}, err
+	}
+
+	return v.Lookup(key, category)
+}
+
+// set creates or updates the variable with the attributes a in the collection.
+// The description of an existing variable is kept when a has none.
+func (c *Client) set(ctx context.Context, coll varsCollection, a Attributes) (k KeyResult, err error) {
+
+	if a.Hcl {
+		if _, err := hclTokens(a.Value); err != nil {
+			return k, fmt.Errorf("%s invalid hcl value: %s", a.Key, err)
+		}
+	}
+
+	current, err := coll.list(ctx)
+
+	if err != nil {
+		return k, err
+	}
+
+	if e, found := current.Find(a.Key, a.Category); found && a.Description == "" {
+		a.Description = e.Description
+	}
+
+	k = upsertKey(ctx, coll, &current, a)
+
+	c.logResult(k)
+
+	return k, k.Err
+}
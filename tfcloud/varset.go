@@ -0,0 +1,267 @@
+package tfcloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const (
+	TF_CLOUD_VARSETS_URL           = "/api/v2/organizations/%s/varsets"
+	TF_CLOUD_VARSET_VARS_URL       = "/api/v2/varsets/%s/relationships/vars"
+	TF_CLOUD_VARSET_WORKSPACES_URL = "/api/v2/varsets/%s/relationships/workspaces"
+)
+
+type VarSetAttributes struct {
+	Description string `json:"description"`
+	Global      bool   `json:"global"`
+	Name        string `json:"name"`
+}
+
+type VarSetWorkspaces struct {
+	Data []ConfigData `json:"data"`
+}
+
+type VarSetRelationships struct {
+	Workspaces VarSetWorkspaces `json:"workspaces"`
+}
+
+type VarSetData struct {
+	Attributes    VarSetAttributes    `json:"attributes"`
+	ID            string              `json:"id"`
+	Relationships VarSetRelationships `json:"relationships"`
+	Type          string              `json:"type"`
+}
+
+// VarSets are the variable sets of an organization.
+type VarSets struct {
+	Data []VarSetData `json:"data"`
+}
+
+type VarSetPayloadData struct {
+	Attributes `json:"attributes"`
+	ID         string `json:"id,omitempty"`
+	Type       string `json:"type"`
+}
+
+// VarSetPayload creates or updates a variable of a variable set.
+type VarSetPayload struct {
+	Data VarSetPayloadData `json:"data"`
+}
+
+// VarSetWorkspacesPayload attaches or detaches workspaces to a variable set.
+type VarSetWorkspacesPayload struct {
+	Data []ConfigData `json:"data"`
+}
+
+func (s *VarSets) Json(indent bool) (data string, err error) {
+	return tojson(s, indent)
+}
+
+// GetVarSets reads all the variable sets of the organization.
+// org is the organization name
+func (c *Client) GetVarSets(ctx context.Context, org string) (s VarSets, err error) {
+
+	err = c.getPages(ctx, c.url(TF_CLOUD_VARSETS_URL, url.PathEscape(org)), func(body []byte) error {
+		p := VarSets{}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			return err
+		}
+
+		s.Data = append(s.Data, p.Data...)
+
+		return nil
+	})
+
+	return s, err
+}
+
+// varSetVars are the variables of the variable set with the given id.
+type varSetVars struct {
+	c  *Client
+	id string
+}
+
+func (s varSetVars) url() string {
+	return s.c.url(TF_CLOUD_VARSET_VARS_URL, s.id)
+}
+
+func (s varSetVars) list(ctx context.Context) (v TerraformVars, err error) {
+
+	err = s.c.getPages(ctx, s.url(), func(body []byte) error {
+		p := TerraformVars{}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			return err
+		}
+
+		v.Data = append(v.Data, p.Data...)
+
+		return nil
+	})
+
+	return v, err
+}
+
+func newVarSetPayload(a Attributes) VarSetPayload {
+	p := VarSetPayload{}
+	p.Data.Type = "vars"
+	p.Data.Attributes.Key = a.Key
+	p.Data.Attributes.Value = a.Value
+	p.Data.Attributes.Description = a.Description
+	p.Data.Attributes.Category = a.Category
+	p.Data.Attributes.Hcl = a.Hcl
+	p.Data.Attributes.Sensitive = a.Sensitive
+
+	return p
+}
+
+func (s varSetVars) create(ctx context.Context, a Attributes) (string, error) {
+	r := VarResponse{}
+	err := s.c.sendFor(ctx, "POST", s.url(), newVarSetPayload(a), http.StatusCreated, &r)
+	return r.Data.ID, err
+}
+
+func (s varSetVars) update(ctx context.Context, id string, a Attributes) error {
+	p := newVarSetPayload(a)
+	p.Data.ID = id
+	return s.c.send(ctx, "PATCH", s.url()+"/"+id, p, http.StatusOK)
+}
+
+func (s varSetVars) remove(ctx context.Context, id string) error {
+	return s.c.send(ctx, "DELETE", s.url()+"/"+id, nil, http.StatusNoContent)
+}
+
+// GetVarSetVars reads all the variables of the variable set.
+// id is the variable set id
+func (c *Client) GetVarSetVars(ctx context.Context, id string) (TerraformVars, error) {
+	return varSetVars{c, id}.list(ctx)
+}
+
+// GetVarSetVar returns the variable of the variable set with the key in the
+// category, or in any category if empty.
+// id is the variable set id
+func (c *Client) GetVarSetVar(ctx context.Context, id string, key string, category string) (Data, error) {
+	return c.lookup(ctx, varSetVars{c, id}, key, category)
+}
+
+// SetVarSetVar creates the variable with the attributes a in the variable
+// set, or updates the one already defined with the same key and category.
+// id is the variable set id
+func (c *Client) SetVarSetVar(ctx context.Context, id string, a Attributes) (KeyResult, error) {
+	return c.set(ctx, varSetVars{c, id}, a)
+}
+
+// PostVarSetVars creates all the variables of v in the variable set.
+// id is the variable set id
+func (c *Client) PostVarSetVars(ctx context.Context, id string, v *TerraformVars) (r Result, err error) {
+	return c.create(ctx, varSetVars{c, id}, v)
+}
+
+// UpsertVarSetVars creates the variables missing in the variable set and
+// updates the ones that already exist with different attributes.
+// id is the variable set id
+func (c *Client) UpsertVarSetVars(ctx context.Context, id string, v *TerraformVars) (r Result, err error) {
+	return c.upsert(ctx, varSetVars{c, id}, v)
+}
+
+// DeleteVarSetVars removes all the variables of v from the variable set.
+// id is the variable set id
+func (c *Client) DeleteVarSetVars(ctx context.Context, id string, v *TerraformVars) (r Result, err error) {
+	return c.delete(ctx, varSetVars{c, id}, v)
+}
+
+func workspacesPayload(workspaces []string) VarSetWorkspacesPayload {
+
+	p := VarSetWorkspacesPayload{}
+
+	for _, w := range workspaces {
+		p.Data = append(p.Data, ConfigData{ID: w, Type: "workspaces"})
+	}
+
+	return p
+}
+
+// AttachVarSet applies the variable set to the workspaces.
+// id is the variable set id
+func (c *Client) AttachVarSet(ctx context.Context, id string, workspaces []string) error {
+	return c.send(ctx, "POST", c.url(TF_CLOUD_VARSET_WORKSPACES_URL, id), workspacesPayload(workspaces), http.StatusNoContent)
+}
+
+// DetachVarSet removes the variable set from the workspaces.
+// id is the variable set id
+func (c *Client) DetachVarSet(ctx context.Context, id string, workspaces []string) error {
+	return c.send(ctx, "DELETE", c.url(TF_CLOUD_VARSET_WORKSPACES_URL, id), workspacesPayload(workspaces), http.StatusNoContent)
+}
+
+// Get all the variable sets of the organization.
+// org is the organization name
+// t is the bearer token
+func (s *VarSets) Get(org string, t string) (err error) {
+
+	r, err := defaultClient(t).GetVarSets(context.Background(), org)
+
+	if err != nil {
+		return err
+	}
+
+	s.Data = r.Data
+
+	return nil
+}
+
+// GetVarSet reads all the variables of the variable set.
+// id is the variable set id
+// t is the bearer token
+func (v *TerraformVars) GetVarSet(id string, t string) (err error) {
+
+	r, err := defaultClient(t).GetVarSetVars(context.Background(), id)
+
+	if err != nil {
+		return err
+	}
+
+	v.Data = r.Data
+
+	return nil
+}
+
+// PostVarSet creates all the variables of v in the variable set.
+// The error combines the errors of the failed variables as KeyErrors.
+// id is the variable set id
+// t is the bearer token
+func (v *TerraformVars) PostVarSet(id string, t string) (r Result, err error) {
+	return defaultClient(t).PostVarSetVars(context.Background(), id, v)
+}
+
+// UpsertVarSet creates the variables missing in the variable set and
+// updates the ones that already exist with different attributes.
+// id is the variable set id
+// t is the bearer token
+func (v *TerraformVars) UpsertVarSet(id string, t string) (r Result, err error) {
+	return defaultClient(t).UpsertVarSetVars(context.Background(), id, v)
+}
+
+// DeleteVarSet removes all the variables of v from the variable set.
+// The error combines the errors of the failed variables as KeyErrors.
+// id is the variable set id
+// t is the bearer token
+func (v *TerraformVars) DeleteVarSet(id string, t string) (r Result, err error) {
+	return defaultClient(t).DeleteVarSetVars(context.Background(), id, v)
+}
+
+// AttachVarSet applies the variable set to the workspaces.
+// id is the variable set id
+// t is the bearer token
+func AttachVarSet(id string, workspaces []string, t string) error {
+	return defaultClient(t).AttachVarSet(context.Background(), id, workspaces)
+}
+
+// DetachVarSet removes the variable set from the workspaces.
+// id is the variable set id
+// t is the bearer token
+func DetachVarSet(id string, workspaces []string, t string) error {
+	return defaultClient(t).DetachVarSet(context.Background(), id, workspaces)
+}
@@ -0,0 +1,174 @@
+package tfcloud
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+func TestGetVarSets(t *testing.T) {
+
+	j := `{
+		"data": [
+		  {
+			"id": "varset-kjkN545LH2Sfercv",
+			"type": "varsets",
+			"attributes": {
+			  "name": "aws-credentials",
+			  "description": "shared aws credentials",
+			  "global": false
+			},
+			"relationships": {
+			  "workspaces": {
+				"data": [
+				  {
+					"id": "ws-UohFdKAHUGsQ8Dtf",
+					"type": "workspaces"
+				  }
+				]
+			  }
+			}
+		  }
+		]
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+		}, nil
+	}
+
+	s := VarSets{}
+
+	if err := s.Get("test", ""); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	expected := "aws-credentials"
+
+	if len(s.Data) != 1 || s.Data[0].Attributes.Name != expected {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, s.Data))
+		t.FailNow()
+	}
+
+	if s.Data[0].Relationships.Workspaces.Data[0].ID != "ws-UohFdKAHUGsQ8Dtf" {
+		t.Log("error expected the workspace ws-UohFdKAHUGsQ8Dtf")
+		t.Fail()
+	}
+}
+
+func TestUpsertVarSet(t *testing.T) {
+
+	j := `{
+		"data": [
+		  {
+			"id": "var-region",
+			"type": "vars",
+			"attributes": {
+			  "key": "AWS_REGION",
+			  "value": "eu-west-1",
+			  "sensitive": false,
+			  "category": "env",
+			  "hcl": false
+			}
+		  }
+		]
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	requests := map[string]string{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		requests[req.Method] = req.URL.Path
+
+		status := map[string]int{"GET": 200, "PATCH": 200, "POST": 201}[req.Method]
+
+		body := j
+
+		if req.Method == "POST" {
+			body = `{"data": {"id": "var-profile", "type": "vars"}}`
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+
+	v := TerraformVars{}
+	v.Data = append(v.Data,
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_REGION", Value: "eu-south-1"}},
+		Data{Attributes: Attributes{Category: "env", Key: "AWS_PROFILE", Value: "dev"}},
+	)
+
+	r, err := v.UpsertVarSet("varset-test", "")
+
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	if r.Count(StatusUpdated) != 1 || r.Count(StatusCreated) != 1 {
+		t.Log(fmt.Printf("error unexpected result %v", r.Keys))
+		t.Fail()
+	}
+
+	if r.Keys[0].ID != "var-profile" || r.Keys[1].ID != "var-region" {
+		t.Log(fmt.Printf("error unexpected variable ids %v", r.Keys))
+		t.Fail()
+	}
+
+	expected := map[string]string{
+		"GET":   "/api/v2/varsets/varset-test/relationships/vars",
+		"POST":  "/api/v2/varsets/varset-test/relationships/vars",
+		"PATCH": "/api/v2/varsets/varset-test/relationships/vars/var-region",
+	}
+
+	for m, path := range expected {
+		if requests[m] != path {
+			t.Log(fmt.Printf("error expected %s %s actual %s", m, path, requests[m]))
+			t.Fail()
+		}
+	}
+}
+
+func TestAttachVarSet(t *testing.T) {
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	body := ""
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+
+		return &http.Response{
+			StatusCode: 204,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+		}, nil
+	}
+
+	if err := AttachVarSet("varset-test", []string{"ws-a", "ws-b"}, ""); err != nil {
+		t.Log(err)
+		t.Fail()
+	}
+
+	expected := `{"data":[{"id":"ws-a","type":"workspaces"},{"id":"ws-b","type":"workspaces"}]}`
+
+	if expected != body {
+		t.Log(fmt.Printf("error expected %s actual %s", expected, body))
+		t.Fail()
+	}
+}
@@ -0,0 +1,240 @@
+package tfcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	TF_CLOUD_ORGANIZATION_URL = "/api/v2/organizations/%s"
+	TF_CLOUD_WORKSPACES_URL   = "/api/v2/organizations/%s/workspaces"
+	TF_CLOUD_WORKSPACE_URL    = "/api/v2/organizations/%s/workspaces/%s"
+)
+
+type WorkspaceAttributes struct {
+	Name     string   `json:"name"`
+	TagNames []string `json:"tag-names"`
+}
+
+type WorkspaceData struct {
+	Attributes WorkspaceAttributes `json:"attributes"`
+	ID         string              `json:"id"`
+	Type       string              `json:"type"`
+}
+
+type WorkspaceResponse struct {
+	Data WorkspaceData `json:"data"`
+}
+
+// Workspaces are the workspaces of an organization.
+type Workspaces struct {
+	Data []WorkspaceData `json:"data"`
+}
+
+// WorkspaceVars are the variables read from a workspace, or the error
+// returned reading them.
+type WorkspaceVars struct {
+	Workspace WorkspaceData
+	Vars      TerraformVars
+	Err       error
+}
+
+// GetWorkspaces reads all the workspaces of the organization.
+// org is the organization name
+func (c *Client) GetWorkspaces(ctx context.Context, org string) (w Workspaces, err error) {
+
+	err = c.getPages(ctx, c.url(TF_CLOUD_WORKSPACES_URL, url.PathEscape(org)), func(body []byte) error {
+		p := Workspaces{}
+
+		if err := json.Unmarshal(body, &p); err != nil {
+			return err
+		}
+
+		w.Data = append(w.Data, p.Data...)
+
+		return nil
+	})
+
+	return w, err
+}
+
+// HasTags reports whether the workspace has all the tags.
+func (w *WorkspaceData) HasTags(tags []string) bool {
+
+	for _, t := range tags {
+		found := false
+
+		for _, n := range w.Attributes.TagNames {
+			if n == t {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Select returns the workspaces whose name matches the glob pattern, if not
+// empty, and that have all the tags.
+func (w *Workspaces) Select(pattern string, tags []string) (s Workspaces, err error) {
+
+	for _, d := range w.Data {
+		if pattern != "" {
+			match, err := path.Match(pattern, d.Attributes.Name)
+
+			if err != nil {
+				return Workspaces{}, err
+			}
+
+			if !match {
+				continue
+			}
+		}
+
+		if d.HasTags(tags) {
+			s.Data = append(s.Data, d)
+		}
+	}
+
+	return s, nil
+}
+
+// GetWorkspacesVars reads the variables of all the workspaces running at
+// most workers requests at the same time. The result has the order of w.Data.
+func (c *Client) GetWorkspacesVars(ctx context.Context, w *Workspaces, workers int) []WorkspaceVars {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	vars := make([]WorkspaceVars, len(w.Data))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				vars[j].Workspace = w.Data[j]
+				vars[j].Vars, vars[j].Err = c.GetVars(ctx, w.Data[j].ID)
+			}
+		}()
+	}
+
+	for i := range w.Data {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return vars
+}
+
+// GetWorkspaceID returns the id of the workspace called name in the organization org.
+func (c *Client) GetWorkspaceID(ctx context.Context, org string, name string) (id string, err error) {
+
+	status, body, err := c.get(ctx, c.url(TF_CLOUD_WORKSPACE_URL, url.PathEscape(org), url.PathEscape(name)))
+
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// the api answers 404 both for a missing organization and a missing workspace.
+		s, b, err := c.get(ctx, c.url(TF_CLOUD_ORGANIZATION_URL, url.PathEscape(org)))
+
+		if err != nil {
+			return "", err
+		}
+
+		if s == http.StatusNotFound {
+			return "", &notFoundError{fmt.Sprintf("organization %q not found", org), newAPIError(s, b)}
+		}
+
+		return "", &notFoundError{fmt.Sprintf("workspace %q not found in organization %q", name, org), newAPIError(status, body)}
+	default:
+		return "", newAPIError(status, body)
+	}
+
+	w := WorkspaceResponse{}
+
+	if err := json.Unmarshal(body, &w); err != nil {
+		return "", err
+	}
+
+	return w.Data.ID, nil
+}
+
+// ResolveWorkspace returns the workspace id referenced by ws.
+// ws can be a workspace id (ws-xxxx), an org/name pair or a workspace name
+// in the organization org. The ids are returned without any request.
+func (c *Client) ResolveWorkspace(ctx context.Context, ws string, org string) (id string, err error) {
+
+	if i := strings.Index(ws, "/"); i >= 0 {
+		return c.GetWorkspaceID(ctx, ws[:i], ws[i+1:])
+	}
+
+	// ids are used as they are, even when the organization is set.
+	if strings.HasPrefix(ws, "ws-") {
+		return ws, nil
+	}
+
+	if org == "" {
+		return "", fmt.Errorf("workspace %q is not an id: use org/name or set the organization", ws)
+	}
+
+	return c.GetWorkspaceID(ctx, org, ws)
+}
+
+// Get all the workspaces of the organization.
+// org is the organization name
+// t is the bearer token
+func (w *Workspaces) Get(org string, t string) (err error) {
+
+	r, err := defaultClient(t).GetWorkspaces(context.Background(), org)
+
+	if err != nil {
+		return err
+	}
+
+	w.Data = r.Data
+
+	return nil
+}
+
+// GetVars reads the variables of all the workspaces running at most
+// workers requests at the same time. The result has the order of w.Data.
+// t is the bearer token
+func (w *Workspaces) GetVars(t string, workers int) []WorkspaceVars {
+	return defaultClient(t).GetWorkspacesVars(context.Background(), w, workers)
+}
+
+// GetWorkspaceID returns the id of the workspace called name in the organization org.
+// t is the bearer token
+func GetWorkspaceID(org string, name string, t string) (id string, err error) {
+	return defaultClient(t).GetWorkspaceID(context.Background(), org, name)
+}
+
+// ResolveWorkspace returns the workspace id referenced by ws.
+// ws can be a workspace id (ws-xxxx), an org/name pair or a workspace name
+// in the organization org. The ids are returned without any request.
+// t is the bearer token
+func ResolveWorkspace(ws string, org string, t string) (id string, err error) {
+	return defaultClient(t).ResolveWorkspace(context.Background(), ws, org)
+}
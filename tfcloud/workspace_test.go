@@ -0,0 +1,213 @@
+package tfcloud
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/uolter/cptfcvars/tfcloud/mocks"
+)
+
+func TestResolveWorkspace(t *testing.T) {
+
+	j := `{
+		"data": {
+		  "id": "ws-SihZTyXKfNXUWuUa",
+		  "type": "workspaces",
+		  "attributes": {
+			"name": "app-prod",
+			"tag-names": ["app"]
+		  }
+		}
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		if req.URL.Path != "/api/v2/organizations/test/workspaces/app-prod" {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(j))),
+		}, nil
+	}
+
+	expected := "ws-SihZTyXKfNXUWuUa"
+
+	for _, ws := range []string{"test/app-prod", "app-prod"} {
+		actual, err := ResolveWorkspace(ws, "test", "")
+
+		if err != nil || expected != actual {
+			t.Log(fmt.Printf("error expected %s actual %s (%v)", expected, actual, err))
+			t.Fail()
+		}
+	}
+
+	// the ids are not looked up as names, with or without the organization.
+	for _, org := range []string{"", "test"} {
+		actual, err := ResolveWorkspace("ws-xxxxxx", org, "")
+
+		if err != nil || actual != "ws-xxxxxx" {
+			t.Log(fmt.Printf("error expected ws-xxxxxx actual %s (%v)", actual, err))
+			t.Fail()
+		}
+	}
+
+	if _, err := ResolveWorkspace("app-prod", "", ""); err == nil {
+		t.Log("error expected a name without organization to fail")
+		t.Fail()
+	}
+}
+
+func TestWorkspaceNotFound(t *testing.T) {
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		status := 404
+
+		if req.URL.Path == "/api/v2/organizations/test" {
+			status = 200
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+
+	_, err := GetWorkspaceID("test", "missing", "")
+
+	expected := `workspace "missing" not found in organization "test"`
+
+	if err == nil || err.Error() != expected || !IsNotFound(err) {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, err))
+		t.Fail()
+	}
+
+	_, err = GetWorkspaceID("other", "missing", "")
+
+	expected = `organization "other" not found`
+
+	if err == nil || err.Error() != expected || !IsNotFound(err) {
+		t.Log(fmt.Printf("error expected %s actual %v", expected, err))
+		t.Fail()
+	}
+}
+
+func TestGetWorkspacesVars(t *testing.T) {
+
+	workspaces := `{
+		"data": [
+		  {"id": "ws-a", "type": "workspaces", "attributes": {"name": "app-dev"}},
+		  {"id": "ws-b", "type": "workspaces", "attributes": {"name": "app-prod"}},
+		  {"id": "ws-c", "type": "workspaces", "attributes": {"name": "broken"}}
+		]
+	  }`
+
+	vars := `{
+		"data": [
+		  {"id": "var-name", "type": "vars", "attributes": {"key": "name", "value": "api", "category": "terraform"}}
+		]
+	  }`
+
+	DefaultHTTPClient = &mocks.MockClient{}
+
+	mocks.GetDoFunc = func(req *http.Request) (*http.Response, error) {
+
+		switch req.URL.Path {
+		case "/api/v2/organizations/test/workspaces":
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(workspaces))),
+			}, nil
+		case "/api/v2/workspaces/ws-c/vars":
+			return &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(vars))),
+		}, nil
+	}
+
+	w := Workspaces{}
+
+	if err := w.Get("test", ""); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	r := w.GetVars("", 2)
+
+	expected := []string{"app-dev", "app-prod", "broken"}
+
+	for i, name := range expected {
+		if r[i].Workspace.Attributes.Name != name {
+			t.Log(fmt.Printf("error expected %s actual %s", name, r[i].Workspace.Attributes.Name))
+			t.Fail()
+		}
+	}
+
+	if r[0].Err != nil || len(r[1].Vars.Data) != 1 || r[2].Err == nil {
+		t.Log(fmt.Printf("error unexpected result %v", r))
+		t.Fail()
+	}
+}
+
+func TestSelectWorkspaces(t *testing.T) {
+
+	w := Workspaces{Data: []WorkspaceData{
+		{ID: "ws-a", Attributes: WorkspaceAttributes{Name: "app-api-prod", TagNames: []string{"app", "prod"}}},
+		{ID: "ws-b", Attributes: WorkspaceAttributes{Name: "app-api-dev", TagNames: []string{"app", "dev"}}},
+		{ID: "ws-c", Attributes: WorkspaceAttributes{Name: "app-web-prod", TagNames: []string{"web", "prod"}}},
+	}}
+
+	cases := []struct {
+		pattern  string
+		tags     []string
+		expected []string
+	}{
+		{"app-*-prod", nil, []string{"ws-a", "ws-c"}},
+		{"", []string{"prod", "app"}, []string{"ws-a"}},
+		{"app-*-prod", []string{"web"}, []string{"ws-c"}},
+		{"db-*", nil, nil},
+	}
+
+	for _, c := range cases {
+		s, err := w.Select(c.pattern, c.tags)
+
+		if err != nil {
+			t.Log(err)
+			t.Fail()
+		}
+
+		actual := []string{}
+
+		for _, d := range s.Data {
+			actual = append(actual, d.ID)
+		}
+
+		if fmt.Sprint(c.expected) != fmt.Sprint(actual) {
+			t.Log(fmt.Printf("error %s %v expected %v actual %v", c.pattern, c.tags, c.expected, actual))
+			t.Fail()
+		}
+	}
+
+	if _, err := w.Select("[", nil); err == nil {
+		t.Log("error expected an invalid pattern to fail")
+		t.Fail()
+	}
+}